@@ -3,12 +3,20 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
+	"rungine/internal/epd"
+	"rungine/internal/filter"
+	"rungine/internal/pgn"
 	"rungine/internal/registry"
+	"rungine/internal/suite"
 	"rungine/internal/uci"
 )
 
@@ -21,6 +29,7 @@ type App struct {
 	engines   *uci.EngineManager
 	registry  *registry.Manager
 	installer *registry.Installer
+	games     *gameStore
 }
 
 // NewApp creates a new App application struct.
@@ -42,6 +51,7 @@ func NewApp() *App {
 		engines:   uci.NewEngineManager(),
 		registry:  regMgr,
 		installer: installer,
+		games:     newGameStore(),
 	}
 }
 
@@ -53,6 +63,12 @@ func (a *App) startup(ctx context.Context) {
 	a.engines.SetAnalysisCallback(func(info uci.AnalysisInfo) {
 		runtime.EventsEmit(ctx, "analysis:info", info)
 	})
+	a.engines.SetCrashCallback(func(ev uci.CrashEvent) {
+		runtime.EventsEmit(ctx, "engine:crashed", ev)
+	})
+	a.engines.SetRestartCallback(func(ev uci.RestartEvent) {
+		runtime.EventsEmit(ctx, "engine:restarted", ev)
+	})
 
 	// Wire up installer events to frontend
 	if a.installer != nil {
@@ -83,10 +99,27 @@ func (a *App) loadInstalledEngines() {
 	for _, eng := range installed {
 		if err := a.engines.RegisterEngine(eng.ID, eng.BinaryPath); err != nil {
 			slog.Warn("failed to register installed engine", "id", eng.ID, "err", err)
+			continue
+		}
+		if engine, err := a.engines.GetEngine(eng.ID); err == nil {
+			engine.SetStartOptions(savedOptions(&eng))
 		}
 	}
 }
 
+// savedOptions returns the option values saved in an installed engine's config,
+// in order, after its network option unless a value was saved for that too.
+func savedOptions(eng *registry.InstalledEngine) []uci.OptionValue {
+	var opts []uci.OptionValue
+	if _, saved := eng.OptionValues[eng.NetworkOption]; eng.NetworkOption != "" && eng.NetworkPath != "" && !saved {
+		opts = append(opts, uci.OptionValue{Name: eng.NetworkOption, Value: eng.NetworkPath})
+	}
+	for _, name := range eng.OptionNames() {
+		opts = append(opts, uci.OptionValue{Name: name, Value: eng.OptionValues[name]})
+	}
+	return opts
+}
+
 // shutdown is called when the app is closing.
 func (a *App) shutdown(_ context.Context) {
 	a.engines.Shutdown()
@@ -126,13 +159,137 @@ func (a *App) GetEngineOptions(id string) (map[string]uci.UCIOption, error) {
 	return engine.Options(), nil
 }
 
-// SetEngineOption sets a UCI option on an engine.
+// SetEngineOption sets a UCI option on an engine. The value is applied again
+// whenever the engine starts and, for installed engines, saved to their config.
 func (a *App) SetEngineOption(id, name, value string) error {
 	engine, err := a.engines.GetEngine(id)
 	if err != nil {
 		return err
 	}
-	return engine.SetOption(name, value)
+	if err := engine.SetOption(name, value); err != nil {
+		return err
+	}
+
+	opt, ok := engine.Options()[name]
+	if a.installer == nil || !ok || opt.Type == uci.OptionTypeButton {
+		return nil
+	}
+	if err := a.installer.SetOptionValue(id, name, value); err != nil && !errors.Is(err, registry.ErrEngineNotFound) {
+		return fmt.Errorf("save option: %w", err)
+	}
+	return nil
+}
+
+// ApplyProfile sets an installed engine's options from one of its registry
+// profiles, or "recommended", with "auto" values chosen for this machine. The
+// engine must be running: every value is checked against the range the engine
+// reports before any is sent, and options it does not have are skipped.
+// It returns the values set, which are saved like those set by SetEngineOption.
+func (a *App) ApplyProfile(engineID, profileName string) ([]uci.OptionValue, error) {
+	if a.installer == nil {
+		return nil, registry.ErrEngineNotFound
+	}
+	installed, err := a.installer.GetInstalled(engineID)
+	if err != nil {
+		return nil, err
+	}
+	def, err := a.registry.GetEngine(installed.RegistryID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := def.ResolveProfile(profileName, registry.DetectHostResources())
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := a.engines.GetEngine(engineID)
+	if err != nil {
+		return nil, err
+	}
+	if state := engine.State(); state != uci.EngineStateReady && state != uci.EngineStateThinking {
+		return nil, uci.ErrEngineNotRunning
+	}
+
+	options := engine.Options()
+	values := make([]uci.OptionValue, 0, len(settings))
+	for _, s := range settings {
+		opt, ok := options[s.Name]
+		if !ok {
+			slog.Warn("engine lacks profile option", "engine", engineID, "profile", profileName, "option", s.Name)
+			continue
+		}
+		value := s.Value
+		if n, err := strconv.Atoi(value); err == nil && s.Auto && opt.Type == uci.OptionTypeSpin {
+			value = strconv.Itoa(opt.Clamp(n))
+		}
+		if err := opt.Validate(value); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profileName, err)
+		}
+		values = append(values, uci.OptionValue{Name: s.Name, Value: value})
+	}
+
+	for _, v := range values {
+		if err := a.SetEngineOption(engineID, v.Name, v.Value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// ForgetEngineOption stops applying a saved option value when the engine starts,
+// such as one flagged as stale. The running engine keeps its current value.
+func (a *App) ForgetEngineOption(id, name string) error {
+	engine, err := a.engines.GetEngine(id)
+	if err != nil {
+		return err
+	}
+	engine.ForgetOption(name)
+
+	if a.installer == nil {
+		return nil
+	}
+	if err := a.installer.RemoveOptionValue(id, name); err != nil && !errors.Is(err, registry.ErrEngineNotFound) {
+		return err
+	}
+	return nil
+}
+
+// SetEngineChess960 switches an engine in or out of Chess960 (Fischer Random) mode.
+func (a *App) SetEngineChess960(id string, enabled bool) error {
+	engine, err := a.engines.GetEngine(id)
+	if err != nil {
+		return err
+	}
+	return engine.SetChess960(enabled)
+}
+
+// GetEngineTranscript returns an engine's recent I/O, including stderr, after the
+// line numbered after. Pass 0 for all lines kept, or the last Seq seen to tail it.
+func (a *App) GetEngineTranscript(id string, after uint64) ([]uci.TranscriptLine, error) {
+	engine, err := a.engines.GetEngine(id)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Transcript().Lines(after), nil
+}
+
+// ClearEngineTranscript discards an engine's recorded I/O.
+func (a *App) ClearEngineTranscript(id string) error {
+	engine, err := a.engines.GetEngine(id)
+	if err != nil {
+		return err
+	}
+	engine.Transcript().Clear()
+	return nil
+}
+
+// SetEngineLogFile mirrors an engine's I/O to a file, or stops if path is empty.
+func (a *App) SetEngineLogFile(id, path string) error {
+	engine, err := a.engines.GetEngine(id)
+	if err != nil {
+		return err
+	}
+	return engine.SetLogFile(path)
 }
 
 // AnalysisParams holds parameters for starting analysis.
@@ -171,6 +328,114 @@ func (a *App) SetAnalysisThrottle(hz int) {
 	a.engines.SetThrottleRate(hz)
 }
 
+// RestartPolicyParams controls how crashed engines are restarted.
+type RestartPolicyParams struct {
+	MaxCrashes     int  `json:"maxCrashes"` // Crashes within the window before giving up; 0 disables restarts
+	WindowSeconds  int  `json:"windowSeconds"`
+	BackoffMs      int  `json:"backoffMs"` // Delay before the first restart, doubled per further crash
+	MaxBackoffMs   int  `json:"maxBackoffMs"`
+	ResumeAnalysis bool `json:"resumeAnalysis"`
+}
+
+// SetRestartPolicy sets how engines that crash are restarted.
+func (a *App) SetRestartPolicy(params RestartPolicyParams) {
+	a.engines.SetRestartPolicy(uci.RestartPolicy{
+		MaxCrashes:     params.MaxCrashes,
+		Window:         time.Duration(params.WindowSeconds) * time.Second,
+		Backoff:        time.Duration(params.BackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(params.MaxBackoffMs) * time.Millisecond,
+		ResumeAnalysis: params.ResumeAnalysis,
+	})
+}
+
+// SuiteParams holds parameters for running an EPD test suite.
+type SuiteParams struct {
+	Path      string   `json:"path"`
+	EngineIDs []string `json:"engineIds"`
+	MoveTime  int      `json:"moveTime"` // milliseconds
+	Depth     int      `json:"depth"`
+	Nodes     int64    `json:"nodes"`
+	Instances int      `json:"instances"` // parallel processes per engine
+	Depths    []int    `json:"depths"`    // also check the PV at these depths
+}
+
+// RunTestSuite runs every position of an EPD file with bm/am operations on the given engines
+// and returns one report per engine. Each finished position is emitted as a "suite:result" event.
+func (a *App) RunTestSuite(params SuiteParams) ([]*suite.Report, error) {
+	f, err := os.Open(params.Path)
+	if err != nil {
+		return nil, err
+	}
+	records, err := epd.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	positions, err := suite.NewPositions(records)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := suite.NewRunner(a.engines, suite.Config{
+		Limit: uci.GoParams{
+			MoveTime: time.Duration(params.MoveTime) * time.Millisecond,
+			Depth:    params.Depth,
+			Nodes:    params.Nodes,
+		},
+		Instances: params.Instances,
+		Depths:    params.Depths,
+	})
+	runner.SetResultCallback(func(res suite.Result) {
+		runtime.EventsEmit(a.ctx, "suite:result", res)
+	})
+	return runner.Run(a.ctx, params.EngineIDs, positions)
+}
+
+// FilterParams holds parameters for filtering a PGN file.
+type FilterParams struct {
+	Input  string         `json:"input"`  // PGN file, possibly compressed
+	Output string         `json:"output"` // PGN file the matching games are written to
+	Filter filter.Options `json:"filter"`
+}
+
+// FilterProgress is emitted while filtering, with how much of the input file has been read.
+type FilterProgress struct {
+	filter.Stats
+	Consumed int64 `json:"consumed"` // Bytes of the input file read, compressed if it is
+	Total    int64 `json:"total"`
+}
+
+// FilterGames writes the games of a PGN file that match the filter to a new PGN file.
+// Progress is emitted as "filter:progress" events.
+func (a *App) FilterGames(params FilterParams) (filter.Stats, error) {
+	f, err := filter.New(params.Filter)
+	if err != nil {
+		return filter.Stats{}, err
+	}
+
+	in, err := pgn.Open(params.Input)
+	if err != nil {
+		return filter.Stats{}, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(params.Output)
+	if err != nil {
+		return filter.Stats{}, err
+	}
+	stats, err := f.Run(a.ctx, in, out, func(s filter.Stats) {
+		consumed, total := in.Progress()
+		runtime.EventsEmit(a.ctx, "filter:progress", FilterProgress{Stats: s, Consumed: consumed, Total: total})
+	})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(params.Output)
+	}
+	return stats, err
+}
+
 // ListAvailableEngines returns engines available for installation from the registry.
 func (a *App) ListAvailableEngines() []registry.EngineInfo {
 	return a.registry.ListEngineInfo()
@@ -196,7 +461,15 @@ func (a *App) InstallEngine(engineID string) error {
 	}
 
 	// Auto-register the newly installed engine
-	return a.engines.RegisterEngine(installed.ID, installed.BinaryPath)
+	if err := a.engines.RegisterEngine(installed.ID, installed.BinaryPath); err != nil {
+		return err
+	}
+	engine, err := a.engines.GetEngine(installed.ID)
+	if err != nil {
+		return err
+	}
+	engine.SetStartOptions(savedOptions(installed))
+	return nil
 }
 
 // UninstallEngine removes an installed engine.
@@ -212,6 +485,85 @@ func (a *App) UninstallEngine(engineID string) error {
 	return a.installer.Uninstall(engineID)
 }
 
+// ListEngineNetworks returns the registry networks of an installed engine with
+// whether each is installed and which one is active.
+func (a *App) ListEngineNetworks(engineID string) ([]registry.NetworkInfo, error) {
+	if a.installer == nil {
+		return nil, registry.ErrEngineNotFound
+	}
+	return a.installer.ListNetworks(engineID)
+}
+
+// InstallEngineNetwork downloads another network for an installed engine.
+// Progress is reported through the install progress events.
+func (a *App) InstallEngineNetwork(engineID, key string) error {
+	if a.installer == nil {
+		return registry.ErrEngineNotFound
+	}
+	return a.installer.InstallNetwork(a.ctx, engineID, key)
+}
+
+// VerifyEngineNetwork checks an installed network against its registry hash.
+func (a *App) VerifyEngineNetwork(engineID, key string) error {
+	if a.installer == nil {
+		return registry.ErrEngineNotFound
+	}
+	return a.installer.VerifyNetwork(engineID, key)
+}
+
+// DeleteEngineNetwork removes an installed network other than the active one.
+func (a *App) DeleteEngineNetwork(engineID, key string) error {
+	if a.installer == nil {
+		return registry.ErrEngineNotFound
+	}
+	return a.installer.DeleteNetwork(engineID, key)
+}
+
+// SetEngineNetwork makes an installed network the one the engine loads. A
+// running engine is restarted with it, which stops its analysis; if it fails
+// to start, the previous network is restored.
+func (a *App) SetEngineNetwork(engineID, key string) error {
+	if a.installer == nil {
+		return registry.ErrEngineNotFound
+	}
+	engine, err := a.engines.GetEngine(engineID)
+	if err != nil {
+		return err
+	}
+	previous, err := a.installer.GetInstalled(engineID)
+	if err != nil {
+		return err
+	}
+
+	installed, err := a.installer.SetActiveNetwork(engineID, key)
+	if err != nil {
+		return err
+	}
+	engine.SetStartOptions(savedOptions(installed))
+	switch engine.State() {
+	case uci.EngineStateReady, uci.EngineStateThinking, uci.EngineStatePondering:
+	default:
+		return nil
+	}
+
+	if err := a.engines.StopEngine(engineID); err != nil {
+		return err
+	}
+	startErr := a.engines.StartEngine(engineID)
+	if startErr == nil || previous.NetworkKey == "" || previous.NetworkKey == key {
+		return startErr
+	}
+
+	slog.Warn("engine failed to start with network, restoring previous", "id", engineID, "network", key, "err", startErr)
+	if restored, err := a.installer.SetActiveNetwork(engineID, previous.NetworkKey); err == nil {
+		engine.SetStartOptions(savedOptions(restored))
+		if err := a.engines.StartEngine(engineID); err != nil {
+			slog.Warn("engine failed to restart with previous network", "id", engineID, "network", previous.NetworkKey, "err", err)
+		}
+	}
+	return fmt.Errorf("network %s: %w", key, startErr)
+}
+
 // GetCPUFeatures returns the detected CPU features.
 func (a *App) GetCPUFeatures() string {
 	return registry.DetectCPUFeatures().FeatureString()
@@ -0,0 +1,105 @@
+// Command pgnfilter copies the games of a PGN file that match a filter to a new PGN file.
+//
+// Usage:
+//
+//	pgnfilter [flags] input.pgn
+//
+// The input may be compressed with gzip, bzip2 or zstd, or be a zip archive
+// holding one PGN file. Criteria of different kinds must all match; repeated
+// criteria of one kind, except -tag, match if any of them does. For example,
+// games where Carlsen had Black in the Najdorf after 2015 that lasted over 60 moves:
+//
+//	pgnfilter -tag 'Black=Carlsen*' -tag 'Date>2015' -eco B90-B99 -minply 121 -o out.pgn games.pgn.zst
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"rungine/internal/filter"
+	"rungine/internal/pgn"
+)
+
+// listFlag collects the values of a flag given several times.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+func main() {
+	var opts filter.Options
+	var tags, results, eco, positions, material listFlag
+	flag.Var(&tags, "tag", "tag criterion like 'White=Carlsen*' or 'WhiteElo>=2500' (repeatable, all must match)")
+	flag.Var(&results, "result", "game result: 1-0, 0-1, 1/2-1/2 or * (repeatable)")
+	flag.Var(&eco, "eco", "ECO code or range like B90-B99 (repeatable)")
+	flag.Var(&positions, "fen", "position reached in the main line, as a FEN or its piece placement (repeatable)")
+	flag.Var(&material, "material", "material reached in the main line, like KRPKR (repeatable)")
+	flag.IntVar(&opts.MinPly, "minply", 0, "minimum number of plies in the main line")
+	flag.IntVar(&opts.MaxPly, "maxply", 0, "maximum number of plies in the main line")
+	output := flag.String("o", "", "output PGN file (default standard output)")
+	quiet := flag.Bool("q", false, "do not print statistics")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] input.pgn\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.Tags, opts.Results, opts.ECO, opts.Positions, opts.Material = tags, results, eco, positions, material
+
+	if err := run(flag.Arg(0), *output, opts, *quiet); err != nil {
+		fmt.Fprintln(os.Stderr, "pgnfilter:", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, output string, opts filter.Options, quiet bool) (err error) {
+	f, err := filter.New(opts)
+	if err != nil {
+		return err
+	}
+
+	in, err := pgn.Open(input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		file, cerr := os.Create(output)
+		if cerr != nil {
+			return cerr
+		}
+		// A failed close may have lost the end of the output
+		defer func() {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		out = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stats, err := f.Run(ctx, in, out, nil)
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "%d games read, %d matched, %d malformed\n", stats.Games, stats.Matched, stats.Errors)
+	}
+	return err
+}
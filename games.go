@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"rungine/internal/fen"
+	"rungine/internal/pgn"
+)
+
+// gameStore holds the games open in the frontend, keyed by ID.
+// Moves within a game are addressed by their path, as returned by pgn.MoveNode.Path.
+type gameStore struct {
+	games  map[string]*pgn.Game
+	nextID int
+	mu     sync.Mutex
+}
+
+func newGameStore() *gameStore {
+	return &gameStore{games: make(map[string]*pgn.Game)}
+}
+
+func (s *gameStore) add(g *pgn.Game) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := "game-" + strconv.Itoa(s.nextID)
+	s.games[id] = g
+	return id
+}
+
+// edit runs fn on the node at path with the store locked.
+func (s *gameStore) edit(id string, path []int, fn func(g *pgn.Game, node *pgn.MoveNode) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return fmt.Errorf("game %s not found", id)
+	}
+	node, err := g.Node(path)
+	if err != nil {
+		return err
+	}
+	return fn(g, node)
+}
+
+// NewGame opens a new empty game and returns its ID.
+func (a *App) NewGame() string {
+	return a.games.add(pgn.NewGame())
+}
+
+// LoadGame opens the first game in PGN text and returns its ID.
+// Games with an illegal move, in the main line or a variation, are rejected.
+func (a *App) LoadGame(pgnText string) (string, error) {
+	g, err := pgn.NewParser(strings.NewReader(pgnText)).ParseGame()
+	if err != nil {
+		return "", err
+	}
+	if err := g.Validate(); err != nil {
+		return "", err
+	}
+	return a.games.add(g), nil
+}
+
+// CloseGame forgets an open game.
+func (a *App) CloseGame(id string) {
+	a.games.mu.Lock()
+	delete(a.games.games, id)
+	a.games.mu.Unlock()
+}
+
+// GetGamePGN returns an open game in PGN export format.
+func (a *App) GetGamePGN(id string) (string, error) {
+	var text string
+	err := a.games.edit(id, nil, func(g *pgn.Game, _ *pgn.MoveNode) error {
+		text = g.String()
+		return nil
+	})
+	return text, err
+}
+
+// AddGameMove plays a move, in SAN or UCI notation, after the node at path.
+// A move that differs from the existing continuation starts a variation.
+// It returns the path of the new (or existing) move.
+func (a *App) AddGameMove(id string, path []int, move string) ([]int, error) {
+	var newPath []int
+	err := a.games.edit(id, path, func(g *pgn.Game, node *pgn.MoveNode) error {
+		pos, err := positionAt(g, node)
+		if err != nil {
+			return err
+		}
+		m, err := pos.ParseSAN(move)
+		if err != nil {
+			if m, err = pos.ParseUCIMove(move); err != nil {
+				return err
+			}
+		}
+		newPath = node.AddMove(pos.SAN(m)).Path()
+		return nil
+	})
+	return newPath, err
+}
+
+// PromoteVariation moves the variation containing the node at path one place up
+// and returns the node's new path.
+func (a *App) PromoteVariation(id string, path []int) ([]int, error) {
+	return a.reorder(id, path, (*pgn.MoveNode).Promote)
+}
+
+// PromoteVariationToMainLine makes the line through the node at path the main line
+// and returns the node's new path.
+func (a *App) PromoteVariationToMainLine(id string, path []int) ([]int, error) {
+	return a.reorder(id, path, (*pgn.MoveNode).PromoteToMainLine)
+}
+
+// DemoteVariation moves the line through the node at path one place down
+// and returns the node's new path.
+func (a *App) DemoteVariation(id string, path []int) ([]int, error) {
+	return a.reorder(id, path, (*pgn.MoveNode).Demote)
+}
+
+// DeleteVariation removes the whole variation containing the node at path.
+func (a *App) DeleteVariation(id string, path []int) error {
+	return a.games.edit(id, path, func(_ *pgn.Game, node *pgn.MoveNode) error {
+		return node.DeleteVariation()
+	})
+}
+
+// DeleteGameMoves removes the node at path and every move after it.
+func (a *App) DeleteGameMoves(id string, path []int) error {
+	return a.games.edit(id, path, func(_ *pgn.Game, node *pgn.MoveNode) error {
+		return node.Delete()
+	})
+}
+
+// TruncateGame removes every move after the node at path.
+func (a *App) TruncateGame(id string, path []int) error {
+	return a.games.edit(id, path, func(_ *pgn.Game, node *pgn.MoveNode) error {
+		node.Truncate()
+		return nil
+	})
+}
+
+func (a *App) reorder(id string, path []int, op func(*pgn.MoveNode) error) ([]int, error) {
+	var newPath []int
+	err := a.games.edit(id, path, func(_ *pgn.Game, node *pgn.MoveNode) error {
+		if err := op(node); err != nil {
+			return err
+		}
+		newPath = node.Path()
+		return nil
+	})
+	return newPath, err
+}
+
+// positionAt returns the position after the node's move.
+func positionAt(g *pgn.Game, node *pgn.MoveNode) (*fen.Position, error) {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return nil, err
+	}
+	for i, san := range node.Moves() {
+		m, err := pos.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("ply %d: %w", i+1, err)
+		}
+		pos.MakeMove(m)
+	}
+	return pos, nil
+}
@@ -0,0 +1,455 @@
+// Package epd provides EPD (Extended Position Description) reading and writing.
+// EPD is the format engine test suites such as WAC, STS and Arasan are distributed in:
+// the first four FEN fields followed by opcode operations like `bm Qg6; id "WAC.001";`.
+package epd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"rungine/internal/fen"
+	"rungine/internal/uci"
+)
+
+// Common opcodes.
+const (
+	OpBestMove      = "bm"   // Best move(s), in SAN
+	OpAvoidMove     = "am"   // Move(s) to avoid, in SAN
+	OpID            = "id"   // Position identifier
+	OpDepth         = "acd"  // Analysis count: depth
+	OpNodes         = "acn"  // Analysis count: nodes
+	OpSeconds       = "acs"  // Analysis count: seconds
+	OpEval          = "ce"   // Centipawn evaluation, from the side to move's point of view
+	OpPV            = "pv"   // Predicted variation, in SAN
+	OpDirectMate    = "dm"   // Direct mate in N moves
+	OpHalfmoveClock = "hmvc" // Halfmove clock
+	OpFullmoveNum   = "fmvn" // Fullmove number
+)
+
+// MateScore is the ce value for delivering mate immediately. A mate in N plies is MateScore-N.
+const MateScore = 32767
+
+var (
+	ErrInvalidEPD = errors.New("invalid EPD")
+)
+
+// Operation is a single EPD opcode with its operands.
+type Operation struct {
+	Opcode   string
+	Operands []string
+
+	quoted []bool // Whether each operand was quoted in the source, for lossless writing
+}
+
+// Record is one EPD line: a position and its operations, in file order.
+type Record struct {
+	FEN        string // The four FEN fields: placement, side to move, castling, en passant
+	Operations []Operation
+
+	// The line the record was parsed from and how String wrote it then,
+	// to write unmodified records back exactly as they were
+	raw, parsed string
+}
+
+// Parse parses a single EPD line.
+func Parse(line string) (*Record, error) {
+	rest := strings.TrimSpace(line)
+	fields := make([]string, 0, 4)
+	for len(fields) < 4 {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return nil, fmt.Errorf("%w: expected 4 FEN fields, got %d", ErrInvalidEPD, len(fields))
+		}
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+
+	rec := &Record{FEN: strings.Join(fields, " ")}
+	if _, err := fen.Parse(rec.FEN); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEPD, err)
+	}
+
+	ops, err := parseOperations(rest)
+	if err != nil {
+		return nil, err
+	}
+	rec.Operations = ops
+	rec.raw = strings.TrimRight(line, "\r\n")
+	rec.parsed = rec.format()
+	return rec, nil
+}
+
+// parseOperations parses the operations following the FEN fields.
+func parseOperations(s string) ([]Operation, error) {
+	var ops []Operation
+	var cur *Operation
+
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == ';':
+			if cur == nil {
+				return nil, fmt.Errorf("%w: empty operation", ErrInvalidEPD)
+			}
+			ops = append(ops, *cur)
+			cur = nil
+			i++
+		case c == '"':
+			if cur == nil {
+				return nil, fmt.Errorf("%w: quoted string where opcode expected", ErrInvalidEPD)
+			}
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated string in %q operation", ErrInvalidEPD, cur.Opcode)
+			}
+			cur.Operands = append(cur.Operands, s[i+1:i+1+end])
+			cur.quoted = append(cur.quoted, true)
+			i += end + 2
+		default:
+			end := strings.IndexAny(s[i:], " \t;")
+			if end < 0 {
+				end = len(s) - i
+			}
+			token := s[i : i+end]
+			if cur == nil {
+				cur = &Operation{Opcode: token}
+			} else {
+				cur.Operands = append(cur.Operands, token)
+				cur.quoted = append(cur.quoted, false)
+			}
+			i += end
+		}
+	}
+
+	// Tolerate a missing semicolon after the last operation
+	if cur != nil {
+		ops = append(ops, *cur)
+	}
+	return ops, nil
+}
+
+// String returns the record as an EPD line. A record that has not been modified
+// since it was parsed is returned as it was read, spacing and all.
+func (r *Record) String() string {
+	s := r.format()
+	if r.raw != "" && s == r.parsed {
+		return r.raw
+	}
+	return s
+}
+
+// format writes the record in the normal form: single spaces between fields
+// and a semicolon after each operation.
+func (r *Record) format() string {
+	var sb strings.Builder
+	sb.WriteString(r.FEN)
+	for _, op := range r.Operations {
+		sb.WriteByte(' ')
+		sb.WriteString(op.Opcode)
+		for i, operand := range op.Operands {
+			sb.WriteByte(' ')
+			if op.isQuoted(i) {
+				sb.WriteByte('"')
+				sb.WriteString(operand)
+				sb.WriteByte('"')
+			} else {
+				sb.WriteString(operand)
+			}
+		}
+		sb.WriteByte(';')
+	}
+	return sb.String()
+}
+
+// isQuoted reports whether an operand is written as a quoted string.
+// Operands read from a file keep their original form; new ones are quoted when
+// the opcode takes strings or the operand could not be read back otherwise.
+func (op Operation) isQuoted(i int) bool {
+	if i < len(op.quoted) {
+		return op.quoted[i]
+	}
+	operand := op.Operands[i]
+	return isStringOpcode(op.Opcode) || operand == "" || strings.ContainsAny(operand, " \t;\"")
+}
+
+// isStringOpcode returns true for opcodes whose operands are strings.
+func isStringOpcode(opcode string) bool {
+	switch opcode {
+	case OpID, "eco", "nic", "tcgs", "tcri", "tcsi", "c0", "c1", "c2", "c3", "c4", "c5", "c6", "c7", "c8", "c9",
+		"v0", "v1", "v2", "v3", "v4", "v5", "v6", "v7", "v8", "v9":
+		return true
+	}
+	return false
+}
+
+// Position returns the record's position, taking the halfmove clock and fullmove
+// number from the hmvc and fmvn opcodes when present.
+func (r *Record) Position() (*fen.Position, error) {
+	halfmove, fullmove := 0, 1
+	if n, ok := r.Int(OpHalfmoveClock); ok {
+		halfmove = n
+	}
+	if n, ok := r.Int(OpFullmoveNum); ok {
+		fullmove = n
+	}
+	return fen.Parse(fmt.Sprintf("%s %d %d", r.FEN, halfmove, fullmove))
+}
+
+// Get returns the operation for an opcode.
+func (r *Record) Get(opcode string) (Operation, bool) {
+	for _, op := range r.Operations {
+		if op.Opcode == opcode {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// Set sets an opcode's operands, replacing the existing operation in place or
+// appending a new one.
+func (r *Record) Set(opcode string, operands ...string) {
+	op := Operation{Opcode: opcode, Operands: operands}
+	for i := range r.Operations {
+		if r.Operations[i].Opcode == opcode {
+			r.Operations[i] = op
+			return
+		}
+	}
+	r.Operations = append(r.Operations, op)
+}
+
+// Delete removes an opcode from the record.
+func (r *Record) Delete(opcode string) {
+	ops := r.Operations[:0]
+	for _, op := range r.Operations {
+		if op.Opcode != opcode {
+			ops = append(ops, op)
+		}
+	}
+	r.Operations = ops
+}
+
+// Strings returns the operands of an opcode, or nil if it is absent.
+func (r *Record) Strings(opcode string) []string {
+	op, _ := r.Get(opcode)
+	return op.Operands
+}
+
+// StringValue returns the first operand of an opcode, or "" if it is absent.
+func (r *Record) StringValue(opcode string) string {
+	if operands := r.Strings(opcode); len(operands) > 0 {
+		return operands[0]
+	}
+	return ""
+}
+
+// Int returns the first operand of an opcode as an integer.
+func (r *Record) Int(opcode string) (int, bool) {
+	n, err := strconv.Atoi(r.StringValue(opcode))
+	return n, err == nil
+}
+
+// ID returns the position identifier (id opcode).
+func (r *Record) ID() string {
+	return r.StringValue(OpID)
+}
+
+// Comment returns the cN comment, for N from 0 to 9.
+func (r *Record) Comment(n int) string {
+	return r.StringValue("c" + strconv.Itoa(n))
+}
+
+// BestMoves returns the best moves (bm opcode) in SAN.
+func (r *Record) BestMoves() []string {
+	return r.Strings(OpBestMove)
+}
+
+// AvoidMoves returns the moves to avoid (am opcode) in SAN.
+func (r *Record) AvoidMoves() []string {
+	return r.Strings(OpAvoidMove)
+}
+
+// BestMovesUCI returns the best moves converted to UCI notation for comparison with engine output.
+func (r *Record) BestMovesUCI() ([]string, error) {
+	return r.movesUCI(OpBestMove)
+}
+
+// AvoidMovesUCI returns the moves to avoid converted to UCI notation.
+func (r *Record) AvoidMovesUCI() ([]string, error) {
+	return r.movesUCI(OpAvoidMove)
+}
+
+// movesUCI converts an opcode's SAN operands, each a move from the record's position, to UCI.
+func (r *Record) movesUCI(opcode string) ([]string, error) {
+	pos, err := r.Position()
+	if err != nil {
+		return nil, err
+	}
+	var moves []string
+	for _, san := range r.Strings(opcode) {
+		m, err := pos.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", opcode, san, err)
+		}
+		moves = append(moves, m.String())
+	}
+	return moves, nil
+}
+
+// Depth returns the analysis depth (acd opcode).
+func (r *Record) Depth() (int, bool) {
+	return r.Int(OpDepth)
+}
+
+// Eval returns the centipawn evaluation (ce opcode).
+func (r *Record) Eval() (int, bool) {
+	return r.Int(OpEval)
+}
+
+// PV returns the predicted variation (pv opcode) in SAN.
+func (r *Record) PV() []string {
+	return r.Strings(OpPV)
+}
+
+// SetAnalysis records an engine analysis result as acd, acn, acs, ce and pv opcodes.
+// The principal variation is converted from UCI to SAN; mate scores are written
+// as MateScore minus the distance to mate in plies, and the search time is rounded
+// up to whole seconds. Opcodes the analysis has no value for are removed, so that
+// none are left over from an earlier analysis.
+func (r *Record) SetAnalysis(info uci.AnalysisInfo) error {
+	pos, err := r.Position()
+	if err != nil {
+		return err
+	}
+	pv, err := fen.UCIToSAN(pos, info.PV)
+	if err != nil {
+		return fmt.Errorf("pv: %w", err)
+	}
+
+	r.Set(OpDepth, strconv.Itoa(info.Depth))
+	if info.Nodes > 0 {
+		r.Set(OpNodes, strconv.FormatInt(info.Nodes, 10))
+	} else {
+		r.Delete(OpNodes)
+	}
+	if info.Time > 0 {
+		r.Set(OpSeconds, strconv.FormatInt(int64((info.Time+time.Second-1)/time.Second), 10))
+	} else {
+		r.Delete(OpSeconds)
+	}
+	if ce, ok := centipawnEval(info.Score); ok {
+		r.Set(OpEval, strconv.Itoa(ce))
+	} else {
+		r.Delete(OpEval)
+	}
+	if len(pv) > 0 {
+		r.Set(OpPV, pv...)
+	} else {
+		r.Delete(OpPV)
+	}
+	return nil
+}
+
+// centipawnEval converts a UCI score to an EPD ce value.
+func centipawnEval(s uci.Score) (int, bool) {
+	switch {
+	case s.Mate != nil:
+		n := *s.Mate
+		if n > 0 {
+			return MateScore - (2*n - 1), true
+		}
+		return -(MateScore + 2*n), true
+	case s.Centipawns != nil:
+		return *s.Centipawns, true
+	}
+	return 0, false
+}
+
+// Reader reads EPD records from an input stream, one per line.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader creates a new EPD reader.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &Reader{scanner: scanner}
+}
+
+// Read returns the next record, skipping blank lines. It returns io.EOF at the end of the input.
+func (r *Reader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rec, err := Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Line returns the line number of the last record read.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// ReadAll reads all records from an input stream.
+func ReadAll(r io.Reader) ([]*Record, error) {
+	reader := NewReader(r)
+	var records []*Record
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Writer writes EPD records to an output stream.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter creates a new EPD writer. Call Flush when done.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes a record as a single line.
+func (w *Writer) Write(r *Record) error {
+	if _, err := w.w.WriteString(r.String()); err != nil {
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
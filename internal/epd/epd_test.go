@@ -0,0 +1,269 @@
+package epd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"rungine/internal/uci"
+)
+
+const wacSample = `2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";
+8/7p/5k2/5p2/p1p2P2/Pr1pPK2/1P1R3P/8 b - - bm Rxb2; id "WAC.002";
+
+5rk1/1ppb3p/p1pb4/6q1/3P1p1r/2P1R2P/PP1BQ1P1/5RKN w - - bm Rg3; id "WAC.003"; c0 "Rg3 wins the queen";
+r1bq2rk/pp3pbp/2p1p1pQ/7P/3P4/2PB1N2/PP3PPR/2KR4 w - - bm Qxh7+; am hxg6; id "WAC.004";
+`
+
+func TestReadAll(t *testing.T) {
+	records, err := ReadAll(strings.NewReader(wacSample))
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("ReadAll() returned %d records, want 4", len(records))
+	}
+
+	first := records[0]
+	if first.FEN != "2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - -" {
+		t.Errorf("FEN = %q", first.FEN)
+	}
+	if got := first.ID(); got != "WAC.001" {
+		t.Errorf("ID() = %q, want %q", got, "WAC.001")
+	}
+	if got := first.BestMoves(); !reflect.DeepEqual(got, []string{"Qg6"}) {
+		t.Errorf("BestMoves() = %v, want [Qg6]", got)
+	}
+
+	if got := records[2].Comment(0); got != "Rg3 wins the queen" {
+		t.Errorf("Comment(0) = %q", got)
+	}
+
+	fourth := records[3]
+	if got := fourth.AvoidMoves(); !reflect.DeepEqual(got, []string{"hxg6"}) {
+		t.Errorf("AvoidMoves() = %v, want [hxg6]", got)
+	}
+	bm, err := fourth.BestMovesUCI()
+	if err != nil {
+		t.Fatalf("BestMovesUCI() error: %v", err)
+	}
+	if !reflect.DeepEqual(bm, []string{"h6h7"}) {
+		t.Errorf("BestMovesUCI() = %v, want [h6h7]", bm)
+	}
+	am, err := fourth.AvoidMovesUCI()
+	if err != nil {
+		t.Fatalf("AvoidMovesUCI() error: %v", err)
+	}
+	if !reflect.DeepEqual(am, []string{"h5g6"}) {
+		t.Errorf("AvoidMovesUCI() = %v, want [h5g6]", am)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	lines := []string{
+		`2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";`,
+		`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; c0 "Bb5=10, Bc4=8"; acd 20; ce 35;`,
+		`4k3/8/8/8/8/8/8/4K2R w K - hmvc 12; fmvn 40; noop;`,
+		`4k3/8/8/8/8/8/8/4K2R w K - id bare;`,
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, line := range lines {
+		rec, err := Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", line, err)
+		}
+		if got := rec.String(); got != line {
+			t.Errorf("String() = %q, want %q", got, line)
+		}
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	want := strings.Join(lines, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("written output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestStringUnmodified(t *testing.T) {
+	lines := []string{
+		`2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001"`,
+		"  r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R  w KQkq -\tbm  Bb5 Bc4 ;c0 \"Bb5=10\";  ",
+		`4k3/8/8/8/8/8/8/4K2R w K -`,
+	}
+	for _, line := range lines {
+		rec, err := Parse(line)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", line, err)
+		}
+		if got := rec.String(); got != line {
+			t.Errorf("String() = %q, want the input %q", got, line)
+		}
+	}
+
+	// A modified record is written in the normal form
+	rec, err := Parse(lines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Set(OpDepth, "20")
+	want := `2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001"; acd 20;`
+	if got := rec.String(); got != want {
+		t.Errorf("String() after Set = %q, want %q", got, want)
+	}
+	rec.Operations[0].Operands[0] = "Qh5"
+	if got := rec.String(); !strings.Contains(got, "bm Qh5;") {
+		t.Errorf("String() after editing an operand = %q", got)
+	}
+
+	// Reading and writing a file keeps its lines
+	input := lines[0] + "\n\n" + lines[1] + "\r\n"
+	records, err := ReadAll(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	if want := lines[0] + "\n" + lines[1] + "\n"; buf.String() != want {
+		t.Errorf("written output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	rec, err := Parse(`4k3/8/8/8/8/8/8/4K2R w K - hmvc 12; fmvn 40;`)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	pos, err := rec.Position()
+	if err != nil {
+		t.Fatalf("Position() error: %v", err)
+	}
+	if got := pos.String(); got != "4k3/8/8/8/8/8/8/4K2R w K - 12 40" {
+		t.Errorf("Position() = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"4k3/8/8/8/8/8/8/4K3 w -",
+		"4k3/8/8/8/8/8/8/4K3 x - - bm Kd1;",
+		`4k3/8/8/8/8/8/8/4K3 w - - id "unterminated;`,
+		`4k3/8/8/8/8/8/8/4K3 w - - ;`,
+	}
+	for _, line := range tests {
+		if _, err := Parse(line); !errors.Is(err, ErrInvalidEPD) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidEPD", line, err)
+		}
+	}
+
+	r := NewReader(strings.NewReader("4k3/8/8/8/8/8/8/4K3 w - - id \"ok\";\n\nbad line\n"))
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	_, err := r.Read()
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Read() error = %v, want error on line 3", err)
+	}
+}
+
+func TestSetAnalysis(t *testing.T) {
+	cp := func(n int) *int { return &n }
+
+	tests := []struct {
+		name string
+		info uci.AnalysisInfo
+		want string
+	}{
+		{
+			name: "centipawns",
+			info: uci.AnalysisInfo{
+				Depth: 18,
+				Nodes: 123456,
+				Time:  2500 * time.Millisecond,
+				Score: uci.Score{Centipawns: cp(-42)},
+				PV:    []string{"g1f3", "e8g8", "e1g1"},
+			},
+			want: `r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 18; acn 123456; acs 3; ce -42; pv Nf3 O-O O-O;`,
+		},
+		{
+			name: "under a second",
+			info: uci.AnalysisInfo{Depth: 5, Time: 300 * time.Millisecond, Score: uci.Score{Centipawns: cp(10)}},
+			want: `r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 5; acs 1; ce 10;`,
+		},
+		{
+			name: "mate for side to move",
+			info: uci.AnalysisInfo{Depth: 30, Score: uci.Score{Mate: cp(3)}, PV: []string{"a1a7"}},
+			want: `r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 30; ce 32762; pv Ra7;`,
+		},
+		{
+			name: "getting mated",
+			info: uci.AnalysisInfo{Depth: 30, Score: uci.Score{Mate: cp(-2)}},
+			want: `r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 30; ce -32763;`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, err := Parse(`r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test";`)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if err := rec.SetAnalysis(tc.info); err != nil {
+				t.Fatalf("SetAnalysis() error: %v", err)
+			}
+			if got := rec.String(); got != tc.want {
+				t.Errorf("String() = %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+
+	// Nothing is left over from an earlier analysis
+	rec, _ := Parse(`r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 30; acn 99; acs 9; ce 32762; pv Ra7;`)
+	if err := rec.SetAnalysis(uci.AnalysisInfo{Depth: 1}); err != nil {
+		t.Fatalf("SetAnalysis() error: %v", err)
+	}
+	if got, want := rec.String(), `r3k2r/8/8/8/8/8/8/RN2K1NR w KQkq - id "test"; acd 1;`; got != want {
+		t.Errorf("String() after reanalysis = %q\nwant %q", got, want)
+	}
+
+	rec, _ = Parse(`4k3/8/8/8/8/8/8/4K3 w - -`)
+	if err := rec.SetAnalysis(uci.AnalysisInfo{PV: []string{"e1e3"}}); err == nil {
+		t.Error("SetAnalysis() with illegal pv succeeded")
+	}
+}
+
+func TestSetAndDelete(t *testing.T) {
+	rec, err := Parse(`4k3/8/8/8/8/8/8/4K3 w - - bm Kd2; id "x";`)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	rec.Set(OpBestMove, "Ke2", "Kf2")
+	rec.Set("c0", "two words")
+	rec.Delete(OpID)
+
+	want := `4k3/8/8/8/8/8/8/4K3 w - - bm Ke2 Kf2; c0 "two words";`
+	if got := rec.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if _, err := NewReader(strings.NewReader("")).Read(); err != io.EOF {
+		t.Errorf("Read() on empty input = %v, want io.EOF", err)
+	}
+}
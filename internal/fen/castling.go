@@ -0,0 +1,202 @@
+package fen
+
+import "strings"
+
+// castlingOrder lists the castling rights in FEN order.
+// It also gives the index of each right in Position.castleRooks.
+var castlingOrder = [4]CastlingRights{WhiteKingSide, WhiteQueenSide, BlackKingSide, BlackQueenSide}
+
+// defaultCastleRooks holds the rook squares of standard chess, in castlingOrder.
+var defaultCastleRooks = [4]Square{7, 0, 63, 56}
+
+func castlingIndex(right CastlingRights) int {
+	for i, r := range castlingOrder {
+		if r == right {
+			return i
+		}
+	}
+	return 0
+}
+
+// castlingChar returns the standard FEN letter for a castling right.
+func castlingChar(right CastlingRights) string {
+	switch right {
+	case WhiteKingSide:
+		return "K"
+	case WhiteQueenSide:
+		return "Q"
+	case BlackKingSide:
+		return "k"
+	default:
+		return "q"
+	}
+}
+
+// castlingSide returns the color owning a castling right and whether it is kingside.
+func castlingSide(right CastlingRights) (Color, bool) {
+	c := White
+	if right&(BlackKingSide|BlackQueenSide) != 0 {
+		c = Black
+	}
+	return c, right&(WhiteKingSide|BlackKingSide) != 0
+}
+
+// castlingRook returns the starting square of the rook for a castling right.
+// In standard chess this is always a corner; in Chess960 it comes from the FEN.
+func (p *Position) castlingRook(right CastlingRights) Square {
+	if p.castleRooks == ([4]Square{}) {
+		// Position built without Parse
+		return defaultCastleRooks[castlingIndex(right)]
+	}
+	return p.castleRooks[castlingIndex(right)]
+}
+
+// castlingKingFile returns the file of the given side's king if it stands on its back rank,
+// or the e-file otherwise.
+func (p *Position) castlingKingFile(c Color) int {
+	backRank := Square(0)
+	if c == Black {
+		backRank = 56
+	}
+	for f := Square(0); f < 8; f++ {
+		if p.Board[backRank+f] == colored(WhiteKing, c) {
+			return int(f)
+		}
+	}
+	return 4
+}
+
+// outermostRook returns the rook furthest from the king on the given side of the back rank.
+// This is the rook X-FEN's KQkq letters refer to. If there is none, the corner square is returned.
+func (p *Position) outermostRook(c Color, kingSide bool) Square {
+	backRank, rook := Square(0), colored(WhiteRook, c)
+	if c == Black {
+		backRank = 56
+	}
+	kingFile := Square(p.castlingKingFile(c))
+
+	if kingSide {
+		for f := Square(7); f > kingFile; f-- {
+			if p.Board[backRank+f] == rook {
+				return backRank + f
+			}
+		}
+		return backRank + 7
+	}
+	for f := Square(0); f < kingFile; f++ {
+		if p.Board[backRank+f] == rook {
+			return backRank + f
+		}
+	}
+	return backRank
+}
+
+// castleRight returns the castling right a castling move uses.
+func (p *Position) castleRight(m Move) CastlingRights {
+	kingSide := m.To%8 == 6
+	if p.Chess960 {
+		kingSide = m.To > m.From
+	}
+
+	switch {
+	case m.From < 8 && kingSide:
+		return WhiteKingSide
+	case m.From < 8:
+		return WhiteQueenSide
+	case kingSide:
+		return BlackKingSide
+	default:
+		return BlackQueenSide
+	}
+}
+
+// castlesKingSide returns true if a castling move is kingside (O-O).
+func (p *Position) castlesKingSide(m Move) bool {
+	_, kingSide := castlingSide(p.castleRight(m))
+	return kingSide
+}
+
+// castleSquares returns the king's destination and the rook's origin and destination for a castling move.
+// The king always ends on the g- or c-file and the rook next to it, as in standard chess.
+func (p *Position) castleSquares(m Move) (kingTo, rookFrom, rookTo Square) {
+	rank := m.From / 8 * 8
+	right := p.castleRight(m)
+	rookFrom = p.castlingRook(right)
+	if _, kingSide := castlingSide(right); kingSide {
+		return rank + 6, rookFrom, rank + 5
+	}
+	return rank + 2, rookFrom, rank + 3
+}
+
+// castlingMask returns the castling rights lost when a piece moves from or to sq.
+func (p *Position) castlingMask(sq Square) CastlingRights {
+	mask := NoCastling
+	for _, right := range castlingOrder {
+		if p.castlingRook(right) == sq {
+			mask |= right
+		}
+	}
+	return mask
+}
+
+// castlingString returns the castling field of the FEN.
+// X-FEN uses KQkq where the outermost rook castles and the rook's file otherwise;
+// Shredder-FEN always uses the file (uppercase for White).
+func (p *Position) castlingString(shredder bool) string {
+	if p.Castling == NoCastling {
+		return "-"
+	}
+
+	var sb strings.Builder
+	for _, right := range castlingOrder {
+		if p.Castling&right == 0 {
+			continue
+		}
+		c, kingSide := castlingSide(right)
+		rook := p.castlingRook(right)
+
+		if !shredder && p.outermostRook(c, kingSide) == rook {
+			sb.WriteString(castlingChar(right))
+			continue
+		}
+		file := 'A' + byte(rook%8)
+		if c == Black {
+			file = 'a' + byte(rook%8)
+		}
+		sb.WriteByte(file)
+	}
+	return sb.String()
+}
+
+// ShredderFEN returns the FEN of the position with castling rights written as rook files
+// (e.g., "HAha"), as used by Shredder and some Chess960 tools.
+func (p *Position) ShredderFEN() string {
+	fields := strings.Fields(p.String())
+	fields[2] = p.castlingString(true)
+	return strings.Join(fields, " ")
+}
+
+// Chess960MoveString returns the move in UCI notation with castling written as
+// king takes rook, as engines expect with UCI_Chess960 enabled.
+func (p *Position) Chess960MoveString(m Move) string {
+	if !m.IsCastle() {
+		return m.String()
+	}
+	_, rookFrom, _ := p.castleSquares(m)
+	return SquareToString(m.From) + SquareToString(rookFrom)
+}
+
+// isChess960Setup returns true if any castling right uses a king or rook
+// away from its standard square, which standard castling cannot express.
+func (p *Position) isChess960Setup() bool {
+	for i, right := range castlingOrder {
+		if p.Castling&right == 0 {
+			continue
+		}
+		c, _ := castlingSide(right)
+		if p.castleRooks[i] != defaultCastleRooks[i] || p.castlingKingFile(c) != 4 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package fen
+
+import (
+	"testing"
+)
+
+func TestPerftChess960(t *testing.T) {
+	// Chess960 perft positions from the Chess Programming Wiki
+	tests := []struct {
+		fen    string
+		counts []int64
+	}{
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []int64{21, 528, 12189, 326672}},
+		{"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []int64{21, 807, 18002, 667366}},
+		{"b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []int64{20, 479, 10471, 273318}},
+	}
+
+	for _, tc := range tests {
+		pos, err := Parse(tc.fen)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+		}
+		if !pos.Chess960 {
+			t.Errorf("Parse(%q) did not detect Chess960", tc.fen)
+		}
+		for i, want := range tc.counts {
+			if testing.Short() && i >= 3 {
+				break
+			}
+			if got := pos.Perft(i + 1); got != want {
+				t.Errorf("%s: Perft(%d) = %d, want %d", tc.fen, i+1, got, want)
+			}
+		}
+		if got := pos.ShredderFEN(); got != tc.fen {
+			t.Errorf("ShredderFEN() = %q, want %q", got, tc.fen)
+		}
+	}
+}
+
+func TestParseCastlingNotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		fen      string
+		xfen     string
+		shredder string
+		chess960 bool
+	}{
+		{
+			name:     "standard",
+			fen:      StartingFEN,
+			xfen:     StartingFEN,
+			shredder: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+		},
+		{
+			name:     "shredder standard",
+			fen:      "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+			xfen:     StartingFEN,
+			shredder: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+		},
+		{
+			name:     "chess960 start",
+			fen:      "bnrbkrqn/pppppppp/8/8/8/8/PPPPPPPP/BNRBKRQN w FCfc - 0 1",
+			xfen:     "bnrbkrqn/pppppppp/8/8/8/8/PPPPPPPP/BNRBKRQN w KQkq - 0 1",
+			shredder: "bnrbkrqn/pppppppp/8/8/8/8/PPPPPPPP/BNRBKRQN w FCfc - 0 1",
+			chess960: true,
+		},
+		{
+			name:     "x-fen inner rook",
+			fen:      "4k3/8/8/8/8/8/8/RR2K1RR w Bk - 0 1",
+			xfen:     "4k3/8/8/8/8/8/8/RR2K1RR w Bk - 0 1",
+			shredder: "4k3/8/8/8/8/8/8/RR2K1RR w Bh - 0 1",
+			chess960: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			if got := pos.String(); got != tc.xfen {
+				t.Errorf("String() = %q, want %q", got, tc.xfen)
+			}
+			if got := pos.ShredderFEN(); got != tc.shredder {
+				t.Errorf("ShredderFEN() = %q, want %q", got, tc.shredder)
+			}
+			if pos.Chess960 != tc.chess960 {
+				t.Errorf("Chess960 = %v, want %v", pos.Chess960, tc.chess960)
+			}
+		})
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		uci   string
+		san   string
+		after string
+	}{
+		{
+			name:  "king next to rook",
+			fen:   "r3k3/8/8/8/8/8/8/RK6 w Aa - 0 1",
+			uci:   "b1a1",
+			san:   "O-O-O",
+			after: "r3k3/8/8/8/8/8/8/2KR4 b a - 1 1",
+		},
+		{
+			name:  "king already on destination",
+			fen:   "4k3/8/8/8/8/8/8/6KR w H - 0 1",
+			uci:   "g1h1",
+			san:   "O-O",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+		{
+			name:  "rook jumps over king side",
+			fen:   "4k3/8/8/8/8/8/8/3K3R w H - 0 1",
+			uci:   "d1h1",
+			san:   "O-O",
+			after: "4k3/8/8/8/8/8/8/5RK1 b - - 1 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			m, err := pos.ParseUCIMove(tc.uci)
+			if err != nil {
+				t.Fatalf("ParseUCIMove(%q) error: %v", tc.uci, err)
+			}
+			if !m.IsCastle() {
+				t.Fatalf("ParseUCIMove(%q) is not castling", tc.uci)
+			}
+			if got := pos.SAN(m); got != tc.san {
+				t.Errorf("SAN = %q, want %q", got, tc.san)
+			}
+			if got := pos.Chess960MoveString(m); got != tc.uci {
+				t.Errorf("Chess960MoveString = %q, want %q", got, tc.uci)
+			}
+
+			u := pos.MakeMove(m)
+			if got := pos.ShredderFEN(); got != tc.after {
+				t.Errorf("after castling = %q, want %q", got, tc.after)
+			}
+			if pos.Key() != pos.ComputeKey() {
+				t.Errorf("stale key after castling")
+			}
+			pos.UnmakeMove(m, u)
+			if got := pos.ShredderFEN(); got != tc.fen {
+				t.Errorf("after unmake = %q, want %q", got, tc.fen)
+			}
+		})
+	}
+}
+
+func TestChess960MoveString(t *testing.T) {
+	pos, err := Parse("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	m, err := pos.ParseSAN("O-O")
+	if err != nil {
+		t.Fatalf("ParseSAN() error: %v", err)
+	}
+	if got := m.String(); got != "e1g1" {
+		t.Errorf("String() = %q, want %q", got, "e1g1")
+	}
+	if got := pos.Chess960MoveString(m); got != "e1h1" {
+		t.Errorf("Chess960MoveString() = %q, want %q", got, "e1h1")
+	}
+}
@@ -63,6 +63,10 @@ type Position struct {
 	EnPassant     Square         // En passant target square, or NoSquare
 	HalfmoveClock int            // Halfmove clock for 50-move rule
 	FullmoveNum   int            // Fullmove number
+	Chess960      bool           // Castling follows Chess960 rules; castling moves are king-takes-rook
+
+	castleRooks [4]Square // Starting rook square for each castling right, in castlingOrder
+	key         uint64    // Zobrist key, maintained incrementally by MakeMove/UnmakeMove
 }
 
 var (
@@ -147,6 +151,8 @@ func Parse(fen string) (*Position, error) {
 		pos.FullmoveNum = fm
 	}
 
+	pos.UpdateKey()
+
 	return pos, nil
 }
 
@@ -187,30 +193,67 @@ func parsePiecePlacement(s string, pos *Position) error {
 }
 
 // parseCastling parses the castling availability field.
+// Besides standard KQkq it accepts Shredder-FEN rook files (e.g., "HAha") and
+// X-FEN, where KQkq refer to the outermost rook and a file letter to an inner one.
+// The piece placement must already be parsed.
 func parseCastling(s string, pos *Position) error {
+	pos.castleRooks = defaultCastleRooks
 	if s == "-" {
 		pos.Castling = NoCastling
 		return nil
 	}
 
 	for i := 0; i < len(s); i++ {
-		switch s[i] {
-		case 'K':
-			pos.Castling |= WhiteKingSide
-		case 'Q':
-			pos.Castling |= WhiteQueenSide
-		case 'k':
-			pos.Castling |= BlackKingSide
-		case 'q':
-			pos.Castling |= BlackQueenSide
+		c := s[i]
+		var color Color
+		var right CastlingRights
+		var rook Square
+
+		switch {
+		case c == 'K' || c == 'Q' || c == 'k' || c == 'q':
+			if c == 'k' || c == 'q' {
+				color = Black
+			}
+			kingSide := c == 'K' || c == 'k'
+			rook = pos.outermostRook(color, kingSide)
+			right = castlingRight(color, kingSide)
+		case c >= 'A' && c <= 'H', c >= 'a' && c <= 'h':
+			file, backRank := int(c-'A'), Square(0)
+			if c >= 'a' {
+				color, file, backRank = Black, int(c-'a'), 56
+			}
+			kingFile := pos.castlingKingFile(color)
+			if file == kingFile {
+				return fmt.Errorf("%w: castling rook %q on the king's file", ErrInvalidCastling, string(c))
+			}
+			rook = backRank + Square(file)
+			right = castlingRight(color, file > kingFile)
 		default:
-			return fmt.Errorf("%w: unknown castling flag %q", ErrInvalidCastling, string(s[i]))
+			return fmt.Errorf("%w: unknown castling flag %q", ErrInvalidCastling, string(c))
 		}
+
+		pos.Castling |= right
+		pos.castleRooks[castlingIndex(right)] = rook
 	}
 
+	pos.Chess960 = pos.isChess960Setup()
 	return nil
 }
 
+// castlingRight returns the castling right for a side and direction.
+func castlingRight(c Color, kingSide bool) CastlingRights {
+	switch {
+	case c == White && kingSide:
+		return WhiteKingSide
+	case c == White:
+		return WhiteQueenSide
+	case kingSide:
+		return BlackKingSide
+	default:
+		return BlackQueenSide
+	}
+}
+
 // parseEnPassant parses the en passant target square.
 func parseEnPassant(s string, pos *Position) error {
 	if s == "-" {
@@ -273,24 +316,9 @@ func (p *Position) String() string {
 		sb.WriteByte('b')
 	}
 
-	// Castling
+	// Castling (X-FEN, identical to standard FEN for standard chess)
 	sb.WriteByte(' ')
-	if p.Castling == NoCastling {
-		sb.WriteByte('-')
-	} else {
-		if p.Castling&WhiteKingSide != 0 {
-			sb.WriteByte('K')
-		}
-		if p.Castling&WhiteQueenSide != 0 {
-			sb.WriteByte('Q')
-		}
-		if p.Castling&BlackKingSide != 0 {
-			sb.WriteByte('k')
-		}
-		if p.Castling&BlackQueenSide != 0 {
-			sb.WriteByte('q')
-		}
-	}
+	sb.WriteString(p.castlingString(false))
 
 	// En passant
 	sb.WriteByte(' ')
@@ -0,0 +1,209 @@
+package fen
+
+// MoveFlags describes special properties of a move.
+type MoveFlags byte
+
+const (
+	FlagCapture MoveFlags = 1 << iota
+	FlagDoublePush
+	FlagEnPassant
+	FlagCastle
+)
+
+// Move represents a chess move.
+// Castling moves have FlagCastle set and are stored as the king's origin and destination squares,
+// or as king takes rook when the position is in Chess960 mode.
+type Move struct {
+	From      Square
+	To        Square
+	Promotion Piece // Piece the pawn promotes to, or NoPiece
+	Flags     MoveFlags
+}
+
+// String returns the move in UCI long algebraic notation (e.g., "e2e4", "e7e8q").
+func (m Move) String() string {
+	s := SquareToString(m.From) + SquareToString(m.To)
+	if m.Promotion != NoPiece {
+		s += string(promotionChar(m.Promotion))
+	}
+	return s
+}
+
+// IsCapture returns true if the move captures a piece (including en passant).
+func (m Move) IsCapture() bool {
+	return m.Flags&FlagCapture != 0
+}
+
+// IsCastle returns true if the move is a castling move.
+func (m Move) IsCastle() bool {
+	return m.Flags&FlagCastle != 0
+}
+
+// Undo holds the state needed to take back a move with UnmakeMove.
+type Undo struct {
+	Captured      Piece
+	Castling      CastlingRights
+	EnPassant     Square
+	HalfmoveClock int
+	Key           uint64
+}
+
+// MakeMove plays a move on the position and returns the state needed to undo it.
+// The move must be one generated by LegalMoves for this position.
+func (p *Position) MakeMove(m Move) Undo {
+	u := Undo{
+		Castling:      p.Castling,
+		EnPassant:     p.EnPassant,
+		HalfmoveClock: p.HalfmoveClock,
+		Key:           p.key,
+	}
+
+	us := p.SideToMove
+	piece := p.Board[m.From]
+
+	// Castling, en passant and side to move are re-hashed once the move is on the board
+	p.key ^= p.stateKey()
+
+	switch {
+	case m.Flags&FlagCastle != 0:
+		kingTo, rookFrom, rookTo := p.castleSquares(m)
+		rook := p.Board[rookFrom]
+		p.removePiece(m.From)
+		p.removePiece(rookFrom)
+		p.putPiece(kingTo, piece)
+		p.putPiece(rookTo, rook)
+	case m.Flags&FlagEnPassant != 0:
+		capSq := enPassantVictim(m.To, us)
+		u.Captured = p.Board[capSq]
+		p.removePiece(capSq)
+		p.removePiece(m.From)
+		p.putPiece(m.To, piece)
+	default:
+		u.Captured = p.Board[m.To]
+		p.removePiece(m.To)
+		p.removePiece(m.From)
+		if m.Promotion != NoPiece {
+			p.putPiece(m.To, m.Promotion)
+		} else {
+			p.putPiece(m.To, piece)
+		}
+	}
+
+	// Update castling rights
+	if pieceKind(piece) == WhiteKing {
+		if us == White {
+			p.Castling &^= WhiteKingSide | WhiteQueenSide
+		} else {
+			p.Castling &^= BlackKingSide | BlackQueenSide
+		}
+	}
+	p.Castling &^= p.castlingMask(m.From) | p.castlingMask(m.To)
+
+	// Update en passant square
+	p.EnPassant = NoSquare
+	if m.Flags&FlagDoublePush != 0 {
+		p.EnPassant = (m.From + m.To) / 2
+	}
+
+	// Update clocks
+	if pieceKind(piece) == WhitePawn || u.Captured != NoPiece {
+		p.HalfmoveClock = 0
+	} else {
+		p.HalfmoveClock++
+	}
+	if us == Black {
+		p.FullmoveNum++
+	}
+
+	p.SideToMove = us ^ 1
+	p.key ^= p.stateKey()
+	return u
+}
+
+// UnmakeMove takes back a move previously played with MakeMove.
+func (p *Position) UnmakeMove(m Move, u Undo) {
+	p.SideToMove ^= 1
+	us := p.SideToMove
+
+	switch {
+	case m.Flags&FlagCastle != 0:
+		kingTo, rookFrom, rookTo := p.castleSquares(m)
+		king := p.Board[kingTo]
+		rook := p.Board[rookTo]
+		p.Board[kingTo] = NoPiece
+		p.Board[rookTo] = NoPiece
+		p.Board[m.From] = king
+		p.Board[rookFrom] = rook
+	case m.Flags&FlagEnPassant != 0:
+		p.Board[m.From] = p.Board[m.To]
+		p.Board[m.To] = NoPiece
+		p.Board[enPassantVictim(m.To, us)] = u.Captured
+	default:
+		piece := p.Board[m.To]
+		if m.Promotion != NoPiece {
+			piece = colored(WhitePawn, us)
+		}
+		p.Board[m.From] = piece
+		p.Board[m.To] = u.Captured
+	}
+
+	p.Castling = u.Castling
+	p.EnPassant = u.EnPassant
+	p.HalfmoveClock = u.HalfmoveClock
+	p.key = u.Key
+	if us == Black {
+		p.FullmoveNum--
+	}
+}
+
+// putPiece places a piece on an empty square and updates the key.
+func (p *Position) putPiece(sq Square, piece Piece) {
+	p.Board[sq] = piece
+	p.key ^= pieceKey(piece, sq)
+}
+
+// removePiece clears a square, if occupied, and updates the key.
+func (p *Position) removePiece(sq Square) {
+	if piece := p.Board[sq]; piece != NoPiece {
+		p.Board[sq] = NoPiece
+		p.key ^= pieceKey(piece, sq)
+	}
+}
+
+// enPassantVictim returns the square of the pawn captured en passant on target.
+func enPassantVictim(target Square, us Color) Square {
+	if us == White {
+		return target - 8
+	}
+	return target + 8
+}
+
+// pieceKind returns the white piece of the same type, ignoring color.
+func pieceKind(p Piece) Piece {
+	if p >= BlackPawn {
+		return p - 6
+	}
+	return p
+}
+
+// colored returns the piece of the given kind (as a white piece) for color c.
+func colored(kind Piece, c Color) Piece {
+	if c == Black {
+		return kind + 6
+	}
+	return kind
+}
+
+// promotionChar returns the lowercase UCI character for a promotion piece.
+func promotionChar(p Piece) byte {
+	switch pieceKind(p) {
+	case WhiteKnight:
+		return 'n'
+	case WhiteBishop:
+		return 'b'
+	case WhiteRook:
+		return 'r'
+	default:
+		return 'q'
+	}
+}
@@ -0,0 +1,308 @@
+package fen
+
+// Precomputed attack tables, indexed by square.
+var (
+	knightTargets [64][]Square
+	kingTargets   [64][]Square
+	rays          [64][8][]Square // Directions 0-3 are orthogonal, 4-7 diagonal
+)
+
+var (
+	knightDeltas = [8][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingDeltas   = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+var promotionKinds = [4]Piece{WhiteQueen, WhiteRook, WhiteBishop, WhiteKnight}
+
+func init() {
+	for sq := 0; sq < 64; sq++ {
+		file, rank := sq%8, sq/8
+		for _, d := range knightDeltas {
+			if f, r := file+d[0], rank+d[1]; onBoard(f, r) {
+				knightTargets[sq] = append(knightTargets[sq], Square(r*8+f))
+			}
+		}
+		for dir, d := range kingDeltas {
+			if f, r := file+d[0], rank+d[1]; onBoard(f, r) {
+				kingTargets[sq] = append(kingTargets[sq], Square(r*8+f))
+			}
+			for f, r := file+d[0], rank+d[1]; onBoard(f, r); f, r = f+d[0], r+d[1] {
+				rays[sq][dir] = append(rays[sq][dir], Square(r*8+f))
+			}
+		}
+	}
+}
+
+func onBoard(file, rank int) bool {
+	return file >= 0 && file < 8 && rank >= 0 && rank < 8
+}
+
+// KingSquare returns the square of the given side's king, or NoSquare if there is none.
+func (p *Position) KingSquare(c Color) Square {
+	king := colored(WhiteKing, c)
+	for sq := Square(0); sq < 64; sq++ {
+		if p.Board[sq] == king {
+			return sq
+		}
+	}
+	return NoSquare
+}
+
+// IsAttacked returns true if the square is attacked by any piece of the given color.
+func (p *Position) IsAttacked(sq Square, by Color) bool {
+	// Pawns attack diagonally forward, so look diagonally backward from sq
+	file, rank := int(sq%8), int(sq/8)
+	pawnRank := rank - 1
+	if by == Black {
+		pawnRank = rank + 1
+	}
+	pawn := colored(WhitePawn, by)
+	for _, f := range [2]int{file - 1, file + 1} {
+		if onBoard(f, pawnRank) && p.Board[pawnRank*8+f] == pawn {
+			return true
+		}
+	}
+
+	knight := colored(WhiteKnight, by)
+	for _, t := range knightTargets[sq] {
+		if p.Board[t] == knight {
+			return true
+		}
+	}
+
+	king := colored(WhiteKing, by)
+	for _, t := range kingTargets[sq] {
+		if p.Board[t] == king {
+			return true
+		}
+	}
+
+	queen := colored(WhiteQueen, by)
+	for dir := 0; dir < 8; dir++ {
+		slider := colored(WhiteRook, by)
+		if dir >= 4 {
+			slider = colored(WhiteBishop, by)
+		}
+		for _, t := range rays[sq][dir] {
+			piece := p.Board[t]
+			if piece == NoPiece {
+				continue
+			}
+			if piece == slider || piece == queen {
+				return true
+			}
+			break
+		}
+	}
+
+	return false
+}
+
+// InCheck returns true if the side to move is in check.
+func (p *Position) InCheck() bool {
+	king := p.KingSquare(p.SideToMove)
+	return king != NoSquare && p.IsAttacked(king, p.SideToMove^1)
+}
+
+// LegalMoves returns all legal moves for the side to move.
+func (p *Position) LegalMoves() []Move {
+	pseudo := p.pseudoLegalMoves()
+	legal := pseudo[:0]
+
+	us := p.SideToMove
+	for _, m := range pseudo {
+		u := p.MakeMove(m)
+		king := p.KingSquare(us)
+		if king == NoSquare || !p.IsAttacked(king, us^1) {
+			legal = append(legal, m)
+		}
+		p.UnmakeMove(m, u)
+	}
+	return legal
+}
+
+// pseudoLegalMoves generates moves that obey piece movement rules but may leave the king in check.
+// Castling moves are fully checked here since their legality depends on attacked transit squares.
+func (p *Position) pseudoLegalMoves() []Move {
+	moves := make([]Move, 0, 48)
+	us := p.SideToMove
+
+	for from := Square(0); from < 64; from++ {
+		piece := p.Board[from]
+		if piece == NoPiece || PieceColor(piece) != us {
+			continue
+		}
+
+		switch pieceKind(piece) {
+		case WhitePawn:
+			moves = p.appendPawnMoves(moves, from)
+		case WhiteKnight:
+			moves = p.appendStepMoves(moves, from, knightTargets[from])
+		case WhiteBishop:
+			moves = p.appendSlideMoves(moves, from, 4, 8)
+		case WhiteRook:
+			moves = p.appendSlideMoves(moves, from, 0, 4)
+		case WhiteQueen:
+			moves = p.appendSlideMoves(moves, from, 0, 8)
+		case WhiteKing:
+			moves = p.appendStepMoves(moves, from, kingTargets[from])
+		}
+	}
+
+	return p.appendCastlingMoves(moves)
+}
+
+func (p *Position) appendPawnMoves(moves []Move, from Square) []Move {
+	us := p.SideToMove
+	file, rank := int(from%8), int(from/8)
+
+	forward, startRank, lastRank := 1, 1, 7
+	if us == Black {
+		forward, startRank, lastRank = -1, 6, 0
+	}
+
+	addPawnMove := func(to Square, flags MoveFlags) {
+		if int(to/8) == lastRank {
+			for _, kind := range promotionKinds {
+				moves = append(moves, Move{From: from, To: to, Promotion: colored(kind, us), Flags: flags})
+			}
+			return
+		}
+		moves = append(moves, Move{From: from, To: to, Flags: flags})
+	}
+
+	// Pushes
+	oneRank := rank + forward
+	if one := Square(oneRank*8 + file); p.Board[one] == NoPiece {
+		addPawnMove(one, 0)
+		if rank == startRank {
+			if two := Square((rank+2*forward)*8 + file); p.Board[two] == NoPiece {
+				moves = append(moves, Move{From: from, To: two, Flags: FlagDoublePush})
+			}
+		}
+	}
+
+	// Captures
+	for _, f := range [2]int{file - 1, file + 1} {
+		if !onBoard(f, oneRank) {
+			continue
+		}
+		to := Square(oneRank*8 + f)
+		target := p.Board[to]
+		if target != NoPiece && PieceColor(target) != us {
+			addPawnMove(to, FlagCapture)
+		} else if to == p.EnPassant && target == NoPiece {
+			moves = append(moves, Move{From: from, To: to, Flags: FlagCapture | FlagEnPassant})
+		}
+	}
+
+	return moves
+}
+
+func (p *Position) appendStepMoves(moves []Move, from Square, targets []Square) []Move {
+	us := p.SideToMove
+	for _, to := range targets {
+		target := p.Board[to]
+		if target == NoPiece {
+			moves = append(moves, Move{From: from, To: to})
+		} else if PieceColor(target) != us {
+			moves = append(moves, Move{From: from, To: to, Flags: FlagCapture})
+		}
+	}
+	return moves
+}
+
+func (p *Position) appendSlideMoves(moves []Move, from Square, firstDir, lastDir int) []Move {
+	us := p.SideToMove
+	for dir := firstDir; dir < lastDir; dir++ {
+		for _, to := range rays[from][dir] {
+			target := p.Board[to]
+			if target == NoPiece {
+				moves = append(moves, Move{From: from, To: to})
+				continue
+			}
+			if PieceColor(target) != us {
+				moves = append(moves, Move{From: from, To: to, Flags: FlagCapture})
+			}
+			break
+		}
+	}
+	return moves
+}
+
+func (p *Position) appendCastlingMoves(moves []Move) []Move {
+	us := p.SideToMove
+	them := us ^ 1
+
+	kingSide, queenSide := WhiteKingSide, WhiteQueenSide
+	rank := Square(0)
+	if us == Black {
+		kingSide, queenSide = BlackKingSide, BlackQueenSide
+		rank = 56
+	}
+	if p.Castling&(kingSide|queenSide) == 0 {
+		return moves
+	}
+
+	king := rank + Square(p.castlingKingFile(us))
+	if p.Board[king] != colored(WhiteKing, us) || p.IsAttacked(king, them) {
+		return moves
+	}
+	rook := colored(WhiteRook, us)
+
+	// The king always lands on the g- or c-file and the rook beside it, wherever they
+	// started (Chess960). Every square either piece crosses must be empty apart from
+	// the two castling pieces, and the king may not cross an attacked square.
+	for _, right := range [2]CastlingRights{kingSide, queenSide} {
+		if p.Castling&right == 0 {
+			continue
+		}
+		rookFrom := p.castlingRook(right)
+		if p.Board[rookFrom] != rook {
+			continue
+		}
+
+		kingTo, rookTo := rank+6, rank+5
+		if right == queenSide {
+			kingTo, rookTo = rank+2, rank+3
+		}
+
+		if !p.castlingPathClear(king, kingTo, king, rookFrom) || !p.castlingPathClear(rookFrom, rookTo, king, rookFrom) {
+			continue
+		}
+		if p.castlingPathAttacked(king, kingTo, them) {
+			continue
+		}
+
+		to := kingTo
+		if p.Chess960 {
+			to = rookFrom
+		}
+		moves = append(moves, Move{From: king, To: to, Flags: FlagCastle})
+	}
+
+	return moves
+}
+
+// castlingPathClear returns true if every square from one square to another on the same rank,
+// inclusive, is empty or holds one of the castling pieces.
+func (p *Position) castlingPathClear(from, to, king, rook Square) bool {
+	lo, hi := min(from, to), max(from, to)
+	for sq := lo; sq <= hi; sq++ {
+		if sq != king && sq != rook && p.Board[sq] != NoPiece {
+			return false
+		}
+	}
+	return true
+}
+
+// castlingPathAttacked returns true if any square the king crosses on the way to its destination is attacked.
+func (p *Position) castlingPathAttacked(king, kingTo Square, by Color) bool {
+	lo, hi := min(king, kingTo), max(king, kingTo)
+	for sq := lo; sq <= hi; sq++ {
+		if sq != king && p.IsAttacked(sq, by) {
+			return true
+		}
+	}
+	return false
+}
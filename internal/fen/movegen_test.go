@@ -0,0 +1,203 @@
+package fen
+
+import (
+	"testing"
+)
+
+func TestPerft(t *testing.T) {
+	// Standard perft positions from the Chess Programming Wiki
+	tests := []struct {
+		name   string
+		fen    string
+		counts []int64 // Node counts for depth 1, 2, 3, ...
+	}{
+		{
+			name:   "starting position",
+			fen:    StartingFEN,
+			counts: []int64{20, 400, 8902, 197281},
+		},
+		{
+			name:   "kiwipete",
+			fen:    "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+			counts: []int64{48, 2039, 97862},
+		},
+		{
+			name:   "position 3",
+			fen:    "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+			counts: []int64{14, 191, 2812, 43238},
+		},
+		{
+			name:   "position 4",
+			fen:    "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+			counts: []int64{6, 264, 9467},
+		},
+		{
+			name:   "position 4 mirrored",
+			fen:    "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+			counts: []int64{6, 264, 9467},
+		},
+		{
+			name:   "position 5",
+			fen:    "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+			counts: []int64{44, 1486, 62379},
+		},
+		{
+			name:   "position 6",
+			fen:    "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+			counts: []int64{46, 2079, 89890},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			for i, want := range tc.counts {
+				depth := i + 1
+				if got := pos.Perft(depth); got != want {
+					t.Errorf("Perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+			// Make/unmake must leave the position untouched
+			if got := pos.String(); got != tc.fen {
+				t.Errorf("position changed after perft: %q", got)
+			}
+		})
+	}
+}
+
+func TestPerftDeep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping deep perft in short mode")
+	}
+
+	pos, err := Parse("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := pos.Perft(4); got != 4085603 {
+		t.Errorf("kiwipete Perft(4) = %d, want 4085603", got)
+	}
+}
+
+func TestDivide(t *testing.T) {
+	pos := StartingPosition()
+	divide := pos.Divide(2)
+
+	if len(divide) != 20 {
+		t.Fatalf("Divide(2) has %d moves, want 20", len(divide))
+	}
+
+	var total int64
+	for _, n := range divide {
+		total += n
+	}
+	if total != 400 {
+		t.Errorf("Divide(2) total = %d, want 400", total)
+	}
+	if divide["e2e4"] != 20 {
+		t.Errorf("Divide(2)[e2e4] = %d, want 20", divide["e2e4"])
+	}
+}
+
+func TestMakeUnmake(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		move  Move
+		after string
+	}{
+		{
+			name:  "double push sets en passant",
+			fen:   StartingFEN,
+			move:  Move{From: StringToSquare("e2"), To: StringToSquare("e4"), Flags: FlagDoublePush},
+			after: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		},
+		{
+			name:  "en passant capture",
+			fen:   "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			move:  Move{From: StringToSquare("e5"), To: StringToSquare("f6"), Flags: FlagCapture | FlagEnPassant},
+			after: "rnbqkbnr/ppp1p1pp/5P2/3p4/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 3",
+		},
+		{
+			name:  "kingside castling",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10",
+			move:  Move{From: StringToSquare("e1"), To: StringToSquare("g1"), Flags: FlagCastle},
+			after: "r3k2r/8/8/8/8/8/8/R4RK1 b kq - 4 10",
+		},
+		{
+			name:  "queenside castling",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 3 10",
+			move:  Move{From: StringToSquare("e8"), To: StringToSquare("c8"), Flags: FlagCastle},
+			after: "2kr3r/8/8/8/8/8/8/R3K2R w KQ - 4 11",
+		},
+		{
+			name:  "promotion with capture",
+			fen:   "r3k3/1P6/8/8/8/8/8/4K3 w q - 0 1",
+			move:  Move{From: StringToSquare("b7"), To: StringToSquare("a8"), Promotion: WhiteQueen, Flags: FlagCapture},
+			after: "Q3k3/8/8/8/8/8/8/4K3 b - - 0 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+
+			u := pos.MakeMove(tc.move)
+			if got := pos.String(); got != tc.after {
+				t.Errorf("after MakeMove(%s):\n  got:  %q\n  want: %q", tc.move, got, tc.after)
+			}
+
+			pos.UnmakeMove(tc.move, u)
+			if got := pos.String(); got != tc.fen {
+				t.Errorf("after UnmakeMove(%s):\n  got:  %q\n  want: %q", tc.move, got, tc.fen)
+			}
+		})
+	}
+}
+
+func TestInCheck(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want bool
+	}{
+		{StartingFEN, false},
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", true},
+		{"4k3/8/8/8/8/8/3n4/4K3 w - - 0 1", false},
+		{"4k3/8/8/8/8/5n2/8/4K3 w - - 0 1", true},
+		{"4k3/8/8/8/8/8/3p4/4K3 w - - 0 1", true},
+	}
+
+	for _, tc := range tests {
+		pos, err := Parse(tc.fen)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+		}
+		if got := pos.InCheck(); got != tc.want {
+			t.Errorf("InCheck(%q) = %v, want %v", tc.fen, got, tc.want)
+		}
+	}
+}
+
+func TestMoveString(t *testing.T) {
+	tests := []struct {
+		move Move
+		want string
+	}{
+		{Move{From: StringToSquare("e2"), To: StringToSquare("e4")}, "e2e4"},
+		{Move{From: StringToSquare("e7"), To: StringToSquare("e8"), Promotion: WhiteQueen}, "e7e8q"},
+		{Move{From: StringToSquare("a2"), To: StringToSquare("a1"), Promotion: BlackKnight}, "a2a1n"},
+		{Move{From: StringToSquare("e1"), To: StringToSquare("g1"), Flags: FlagCastle}, "e1g1"},
+	}
+
+	for _, tc := range tests {
+		if got := tc.move.String(); got != tc.want {
+			t.Errorf("Move.String() = %q, want %q", got, tc.want)
+		}
+	}
+}
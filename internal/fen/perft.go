@@ -0,0 +1,38 @@
+package fen
+
+// Perft counts the leaf nodes of the legal move tree to the given depth.
+// It is the standard way to verify move generation against known results.
+func (p *Position) Perft(depth int) int64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	moves := p.LegalMoves()
+	if depth == 1 {
+		return int64(len(moves))
+	}
+
+	var nodes int64
+	for _, m := range moves {
+		u := p.MakeMove(m)
+		nodes += p.Perft(depth - 1)
+		p.UnmakeMove(m, u)
+	}
+	return nodes
+}
+
+// Divide returns the perft count below each legal move, keyed by the move in UCI notation.
+// Comparing a divide against another engine's output pinpoints move generation bugs.
+func (p *Position) Divide(depth int) map[string]int64 {
+	result := make(map[string]int64)
+	if depth <= 0 {
+		return result
+	}
+
+	for _, m := range p.LegalMoves() {
+		u := p.MakeMove(m)
+		result[m.String()] = p.Perft(depth - 1)
+		p.UnmakeMove(m, u)
+	}
+	return result
+}
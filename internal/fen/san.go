@@ -0,0 +1,269 @@
+package fen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidMove   = errors.New("invalid move notation")
+	ErrIllegalMove   = errors.New("illegal move")
+	ErrAmbiguousMove = errors.New("ambiguous move")
+)
+
+// sanPieceLetters maps piece kinds to their SAN letters.
+var sanPieceLetters = map[Piece]byte{
+	WhiteKnight: 'N', WhiteBishop: 'B', WhiteRook: 'R', WhiteQueen: 'Q', WhiteKing: 'K',
+}
+
+// pieceFromSANLetter maps SAN piece letters to piece kinds.
+var pieceFromSANLetter = map[byte]Piece{
+	'N': WhiteKnight, 'B': WhiteBishop, 'R': WhiteRook, 'Q': WhiteQueen, 'K': WhiteKing,
+}
+
+// ParseUCIMove finds the legal move matching a UCI long algebraic string (e.g., "e2e4", "e7e8q").
+// Castling is accepted both as the king's two-square move and as king-takes-rook.
+func (p *Position) ParseUCIMove(s string) (Move, error) {
+	if len(s) != 4 && len(s) != 5 {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, s)
+	}
+
+	from := StringToSquare(s[0:2])
+	to := StringToSquare(s[2:4])
+	if from == NoSquare || to == NoSquare {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, s)
+	}
+
+	promo := NoPiece
+	if len(s) == 5 {
+		kind, ok := pieceFromSANLetter[s[4]-'a'+'A']
+		if !ok || kind == WhiteKing {
+			return Move{}, fmt.Errorf("%w: bad promotion piece in %q", ErrInvalidMove, s)
+		}
+		promo = colored(kind, p.SideToMove)
+	}
+
+	for _, m := range p.LegalMoves() {
+		if m.From != from || m.Promotion != promo {
+			continue
+		}
+		if m.To == to {
+			return m, nil
+		}
+		if m.IsCastle() && p.castleRookFrom(m) == to {
+			return m, nil
+		}
+	}
+
+	return Move{}, fmt.Errorf("%w: %s", ErrIllegalMove, s)
+}
+
+// ParseSAN finds the legal move matching a move in Standard Algebraic Notation.
+// Check and mate suffixes and annotation symbols ("!", "?") are accepted and ignored.
+func (p *Position) ParseSAN(san string) (Move, error) {
+	s := strings.TrimRight(san, "+#!?")
+	s = strings.TrimSuffix(s, "e.p.")
+
+	// Castling (accept both letter O and digit 0)
+	switch s {
+	case "O-O", "0-0":
+		return p.findCastle(true, san)
+	case "O-O-O", "0-0-0":
+		return p.findCastle(false, san)
+	}
+
+	if len(s) < 2 {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, san)
+	}
+
+	// Promotion suffix: "=Q" or bare "Q"
+	promoKind := NoPiece
+	if kind, ok := pieceFromSANLetter[s[len(s)-1]]; ok && kind != WhiteKing {
+		promoKind = kind
+		s = strings.TrimSuffix(s[:len(s)-1], "=")
+	}
+
+	if len(s) < 2 {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, san)
+	}
+	to := StringToSquare(s[len(s)-2:])
+	if to == NoSquare {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, san)
+	}
+	s = s[:len(s)-2]
+
+	kind := WhitePawn
+	if len(s) > 0 {
+		if k, ok := pieceFromSANLetter[s[0]]; ok {
+			kind = k
+			s = s[1:]
+		}
+	}
+	s = strings.TrimSuffix(s, "x")
+
+	// Remaining characters are disambiguation (file, rank, or both)
+	fromFile, fromRank := -1, -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = int(c - '1')
+		default:
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidMove, san)
+		}
+	}
+
+	var match Move
+	found := 0
+	for _, m := range p.LegalMoves() {
+		if m.To != to || m.IsCastle() || pieceKind(p.Board[m.From]) != kind {
+			continue
+		}
+		if fromFile >= 0 && int(m.From%8) != fromFile {
+			continue
+		}
+		if fromRank >= 0 && int(m.From/8) != fromRank {
+			continue
+		}
+		if pieceKind(m.Promotion) != promoKind {
+			continue
+		}
+		match = m
+		found++
+	}
+
+	switch found {
+	case 0:
+		return Move{}, fmt.Errorf("%w: %s", ErrIllegalMove, san)
+	case 1:
+		return match, nil
+	default:
+		return Move{}, fmt.Errorf("%w: %s", ErrAmbiguousMove, san)
+	}
+}
+
+func (p *Position) findCastle(kingSide bool, san string) (Move, error) {
+	for _, m := range p.LegalMoves() {
+		if m.IsCastle() && p.castlesKingSide(m) == kingSide {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("%w: %s", ErrIllegalMove, san)
+}
+
+// SAN returns the move in Standard Algebraic Notation, including check and mate suffixes.
+// The move must be legal in this position.
+func (p *Position) SAN(m Move) string {
+	var sb strings.Builder
+
+	piece := p.Board[m.From]
+	kind := pieceKind(piece)
+
+	switch {
+	case m.IsCastle():
+		if p.castlesKingSide(m) {
+			sb.WriteString("O-O")
+		} else {
+			sb.WriteString("O-O-O")
+		}
+	case kind == WhitePawn:
+		if m.IsCapture() {
+			sb.WriteByte('a' + byte(m.From%8))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(SquareToString(m.To))
+		if m.Promotion != NoPiece {
+			sb.WriteByte('=')
+			sb.WriteByte(sanPieceLetters[pieceKind(m.Promotion)])
+		}
+	default:
+		sb.WriteByte(sanPieceLetters[kind])
+		sb.WriteString(p.disambiguation(m, kind))
+		if m.IsCapture() {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(SquareToString(m.To))
+	}
+
+	u := p.MakeMove(m)
+	if p.InCheck() {
+		if len(p.LegalMoves()) == 0 {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+	p.UnmakeMove(m, u)
+
+	return sb.String()
+}
+
+// disambiguation returns the file, rank, or square needed to distinguish m from
+// other legal moves of the same piece kind to the same square.
+func (p *Position) disambiguation(m Move, kind Piece) string {
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range p.LegalMoves() {
+		if other.To != m.To || other.From == m.From || other.IsCastle() || pieceKind(p.Board[other.From]) != kind {
+			continue
+		}
+		ambiguous = true
+		if other.From%8 == m.From%8 {
+			sameFile = true
+		}
+		if other.From/8 == m.From/8 {
+			sameRank = true
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string('a' + byte(m.From%8))
+	case !sameRank:
+		return string('1' + byte(m.From/8))
+	default:
+		return SquareToString(m.From)
+	}
+}
+
+// castleRookFrom returns the square of the rook taking part in a castling move.
+func (p *Position) castleRookFrom(m Move) Square {
+	_, from, _ := p.castleSquares(m)
+	return from
+}
+
+// SANToUCI converts a sequence of SAN moves played from pos into UCI notation.
+// The position is not modified.
+func SANToUCI(pos *Position, moves []string) ([]string, error) {
+	p := *pos
+	result := make([]string, 0, len(moves))
+	for i, san := range moves {
+		m, err := p.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		result = append(result, m.String())
+		p.MakeMove(m)
+	}
+	return result, nil
+}
+
+// UCIToSAN converts a sequence of UCI moves played from pos into SAN.
+// It is typically used to display an engine's principal variation. The position is not modified.
+func UCIToSAN(pos *Position, moves []string) ([]string, error) {
+	p := *pos
+	result := make([]string, 0, len(moves))
+	for i, uci := range moves {
+		m, err := p.ParseUCIMove(uci)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		result = append(result, p.SAN(m))
+		p.MakeMove(m)
+	}
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package fen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSAN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		san  string
+		want string // UCI
+	}{
+		{"pawn push", StartingFEN, "e4", "e2e4"},
+		{"knight move", StartingFEN, "Nf3", "g1f3"},
+		{"check suffix", "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "Qh5+", "d1h5"},
+		{"annotation suffix", StartingFEN, "e4!?", "e2e4"},
+		{"pawn capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "exd5", "e4d5"},
+		{"en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", "e5f6"},
+		{"file disambiguation", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rhf1", "h1f1"},
+		{"rank disambiguation", "4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "R1a4", "a1a4"},
+		{"square disambiguation", "4k3/8/8/8/8/Q1Q5/8/Q3K3 w - - 0 1", "Qa3b2", "a3b2"},
+		{"capture with disambiguation", "4k3/8/8/3p4/8/2N1N3/8/4K3 w - - 0 1", "Ncxd5", "c3d5"},
+		{"promotion", "8/4P3/8/8/8/8/8/k6K w - - 0 1", "e8=Q", "e7e8q"},
+		{"underpromotion without equals", "8/4P3/8/8/8/8/8/k6K w - - 0 1", "e8N", "e7e8n"},
+		{"capture promotion with mate", "3r2k1/4Pppp/8/8/8/8/8/4K3 w - - 0 1", "exd8=Q#", "e7d8q"},
+		{"kingside castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "e1g1"},
+		{"queenside castling", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", "e8c8"},
+		{"castling with zeros", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "e1g1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			m, err := pos.ParseSAN(tc.san)
+			if err != nil {
+				t.Fatalf("ParseSAN(%q) error: %v", tc.san, err)
+			}
+			if got := m.String(); got != tc.want {
+				t.Errorf("ParseSAN(%q) = %s, want %s", tc.san, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		san  string
+		want error
+	}{
+		{"garbage", StartingFEN, "xyz", ErrInvalidMove},
+		{"empty", StartingFEN, "", ErrInvalidMove},
+		{"illegal", StartingFEN, "e5", ErrIllegalMove},
+		{"castling through pieces", StartingFEN, "O-O", ErrIllegalMove},
+		{"ambiguous", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "Rf1", ErrAmbiguousMove},
+		{"missing promotion", "8/4P3/8/8/8/8/8/k6K w - - 0 1", "e8", ErrIllegalMove},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			if _, err := pos.ParseSAN(tc.san); !errors.Is(err, tc.want) {
+				t.Errorf("ParseSAN(%q) error = %v, want %v", tc.san, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseUCIMove(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		want string
+	}{
+		{"normal", StartingFEN, "g1f3", "g1f3"},
+		{"promotion", "8/4P3/8/8/8/8/8/k6K w - - 0 1", "e7e8r", "e7e8r"},
+		{"castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1c1", "e1c1"},
+		{"castling as king takes rook", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1h1", "e1g1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			m, err := pos.ParseUCIMove(tc.uci)
+			if err != nil {
+				t.Fatalf("ParseUCIMove(%q) error: %v", tc.uci, err)
+			}
+			if got := m.String(); got != tc.want {
+				t.Errorf("ParseUCIMove(%q) = %s, want %s", tc.uci, got, tc.want)
+			}
+		})
+	}
+
+	pos := StartingPosition()
+	for _, bad := range []string{"", "e2", "e2e5", "i2i4", "e7e8k"} {
+		if _, err := pos.ParseUCIMove(bad); err == nil {
+			t.Errorf("ParseUCIMove(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		want string
+	}{
+		{"pawn push", StartingFEN, "e2e4", "e4"},
+		{"knight", StartingFEN, "b1c3", "Nc3"},
+		{"pawn capture", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "exd5"},
+		{"file disambiguation", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "a1d1", "Rad1"},
+		{"rank disambiguation", "4k3/R7/8/8/8/8/8/R3K3 w - - 0 1", "a7a4", "R7a4"},
+		{"square disambiguation", "4k3/8/8/8/8/Q1Q5/8/Q3K3 w - - 0 1", "a3b2", "Qa3b2"},
+		{"pinned piece needs no disambiguation", "4k3/8/8/b7/8/2N1N3/8/4K3 w - - 0 1", "e3d5", "Nd5"},
+		{"blocked check", "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "d1h5", "Qh5"},
+		{"mate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2", "d8h4", "Qh4#"},
+		{"promotion with check", "8/4P3/8/8/k7/8/8/7K w - - 0 1", "e7e8q", "e8=Q+"},
+		{"kingside castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"queenside castling with check", "3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", "e1c1", "O-O-O+"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			m, err := pos.ParseUCIMove(tc.uci)
+			if err != nil {
+				t.Fatalf("ParseUCIMove(%q) error: %v", tc.uci, err)
+			}
+			if got := pos.SAN(m); got != tc.want {
+				t.Errorf("SAN(%s) = %q, want %q", tc.uci, got, tc.want)
+			}
+			if got := pos.String(); got != tc.fen {
+				t.Errorf("position changed after SAN: %q", got)
+			}
+		})
+	}
+}
+
+func TestSANToUCIRoundTrip(t *testing.T) {
+	san := []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Bxc6", "dxc6", "O-O", "Bg4", "h3", "h5", "hxg4", "hxg4", "Nxe5", "Qh4", "Nxg4", "Qxg4+"}
+	want := []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6", "b5c6", "d7c6", "e1g1", "c8g4", "h2h3", "h7h5", "h3g4", "h5g4", "f3e5", "d8h4", "e5g4", "h4g4"}
+
+	pos := StartingPosition()
+	got, err := SANToUCI(pos, san)
+	if err != nil {
+		t.Fatalf("SANToUCI() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SANToUCI() = %v, want %v", got, want)
+	}
+
+	back, err := UCIToSAN(pos, got)
+	if err != nil {
+		t.Fatalf("UCIToSAN() error: %v", err)
+	}
+	// Qxg4 does not give check, so the suffix is dropped on the way back
+	san[len(san)-1] = "Qxg4"
+	if !reflect.DeepEqual(back, san) {
+		t.Errorf("UCIToSAN() = %v, want %v", back, san)
+	}
+
+	if pos.String() != StartingFEN {
+		t.Errorf("conversion modified the position: %q", pos.String())
+	}
+
+	if _, err := SANToUCI(pos, []string{"e4", "e4"}); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("SANToUCI() with illegal move error = %v, want ErrIllegalMove", err)
+	}
+}
@@ -0,0 +1,40 @@
+package fen
+
+// IsCheckmate returns true if the side to move is in check and has no legal moves.
+func (p *Position) IsCheckmate() bool {
+	return p.InCheck() && len(p.LegalMoves()) == 0
+}
+
+// IsStalemate returns true if the side to move is not in check but has no legal moves.
+func (p *Position) IsStalemate() bool {
+	return !p.InCheck() && len(p.LegalMoves()) == 0
+}
+
+// InsufficientMaterial returns true if neither side can possibly deliver checkmate:
+// bare kings, a single minor piece against a bare king, or only bishops that all
+// stand on squares of the same colour.
+func (p *Position) InsufficientMaterial() bool {
+	var minors, knights int
+	bishopColors := [2]bool{} // Whether any bishop stands on a dark (0) or light (1) square
+
+	for sq := Square(0); sq < 64; sq++ {
+		switch pieceKind(p.Board[sq]) {
+		case NoPiece, WhiteKing:
+		case WhiteKnight:
+			minors++
+			knights++
+		case WhiteBishop:
+			minors++
+			bishopColors[(sq%8+sq/8)%2] = true
+		default:
+			// Any pawn, rook or queen is enough material to mate
+			return false
+		}
+	}
+
+	if minors <= 1 {
+		return true
+	}
+	// Several bishops on one square colour can never attack the other colour
+	return knights == 0 && bishopColors[0] != bishopColors[1]
+}
@@ -0,0 +1,129 @@
+package fen
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidKings = errors.New("invalid king placement")
+	ErrInvalidPawns = errors.New("invalid pawn placement")
+	ErrInvalidCheck = errors.New("side not to move is in check")
+)
+
+// Validate checks that the position is one an engine can safely search.
+// Parse only checks FEN syntax; Validate checks that the position itself makes sense.
+// The returned error wraps one of the ErrInvalid* values.
+func (p *Position) Validate() error {
+	for _, c := range [2]Color{White, Black} {
+		if n := p.count(colored(WhiteKing, c)); n != 1 {
+			return fmt.Errorf("%w: %s has %d kings", ErrInvalidKings, colorName(c), n)
+		}
+		if n := p.count(colored(WhitePawn, c)); n > 8 {
+			return fmt.Errorf("%w: %s has %d pawns", ErrInvalidPawns, colorName(c), n)
+		}
+	}
+
+	for file := Square(0); file < 8; file++ {
+		for _, sq := range [2]Square{file, 56 + file} {
+			if pieceKind(p.Board[sq]) == WhitePawn {
+				return fmt.Errorf("%w: pawn on %s", ErrInvalidPawns, SquareToString(sq))
+			}
+		}
+	}
+
+	them := p.SideToMove ^ 1
+	if p.IsAttacked(p.KingSquare(them), p.SideToMove) {
+		return fmt.Errorf("%w: %s king on %s", ErrInvalidCheck, colorName(them), SquareToString(p.KingSquare(them)))
+	}
+
+	for _, right := range [4]CastlingRights{WhiteKingSide, WhiteQueenSide, BlackKingSide, BlackQueenSide} {
+		if p.Castling&right != 0 && !p.castlingRightPossible(right) {
+			return fmt.Errorf("%w: king or rook has moved for %q", ErrInvalidCastling, castlingChar(right))
+		}
+	}
+
+	if p.EnPassant != NoSquare && !p.enPassantPossible() {
+		return fmt.Errorf("%w: no pawn can have just moved past %s", ErrInvalidEnPassant, SquareToString(p.EnPassant))
+	}
+
+	return nil
+}
+
+// Normalize clears fields that contradict the piece placement: castling rights
+// whose king or rook is not on its original square, and an en passant square
+// no pawn can have just skipped over. It also resets out-of-range move counters.
+// Problems with the pieces themselves are left for Validate to report.
+func (p *Position) Normalize() {
+	for _, right := range [4]CastlingRights{WhiteKingSide, WhiteQueenSide, BlackKingSide, BlackQueenSide} {
+		if p.Castling&right != 0 && !p.castlingRightPossible(right) {
+			p.Castling &^= right
+		}
+	}
+
+	if p.EnPassant != NoSquare && !p.enPassantPossible() {
+		p.EnPassant = NoSquare
+	}
+
+	if p.HalfmoveClock < 0 {
+		p.HalfmoveClock = 0
+	}
+	if p.FullmoveNum < 1 {
+		p.FullmoveNum = 1
+	}
+
+	p.UpdateKey()
+}
+
+// castlingRightPossible returns true if the king and rook for a castling right
+// are still on their original squares.
+func (p *Position) castlingRightPossible(right CastlingRights) bool {
+	c, kingSide := castlingSide(right)
+	backRank := Square(0)
+	if c == Black {
+		backRank = 56
+	}
+
+	kingFile := p.castlingKingFile(c)
+	if p.Board[backRank+Square(kingFile)] != colored(WhiteKing, c) || (!p.Chess960 && kingFile != 4) {
+		return false
+	}
+
+	rook := p.castlingRook(right)
+	if p.Board[rook] != colored(WhiteRook, c) {
+		return false
+	}
+	return (int(rook%8) > kingFile) == kingSide
+}
+
+// enPassantPossible returns true if the en passant square is consistent with
+// the opponent's pawn having just made a double push past it.
+func (p *Position) enPassantPossible() bool {
+	them := p.SideToMove ^ 1
+	rank := p.EnPassant / 8
+	if (them == White && rank != 2) || (them == Black && rank != 5) {
+		return false
+	}
+	victim := enPassantVictim(p.EnPassant, p.SideToMove)
+	origin := enPassantVictim(p.EnPassant, them)
+	return p.Board[victim] == colored(WhitePawn, them) &&
+		p.Board[p.EnPassant] == NoPiece && p.Board[origin] == NoPiece
+}
+
+// count returns the number of pieces of the given type on the board.
+func (p *Position) count(piece Piece) int {
+	n := 0
+	for _, pc := range p.Board {
+		if pc == piece {
+			n++
+		}
+	}
+	return n
+}
+
+func colorName(c Color) string {
+	if c == White {
+		return "white"
+	}
+	return "black"
+}
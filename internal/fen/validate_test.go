@@ -0,0 +1,83 @@
+package fen
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want error
+	}{
+		{"starting position", StartingFEN, nil},
+		{"valid en passant", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 3", nil},
+		{"no white king", "4k3/8/8/8/8/8/8/8 w - - 0 1", ErrInvalidKings},
+		{"two white kings", "4k3/8/8/8/8/8/8/K3K3 w - - 0 1", ErrInvalidKings},
+		{"two black kings", "k3k3/8/8/8/8/8/8/4K3 w - - 0 1", ErrInvalidKings},
+		{"pawn on first rank", "4k3/8/8/8/8/8/8/P3K3 w - - 0 1", ErrInvalidPawns},
+		{"pawn on last rank", "p3k3/8/8/8/8/8/8/4K3 w - - 0 1", ErrInvalidPawns},
+		{"nine pawns", "4k3/8/8/8/8/P7/PPPPPPPP/4K3 w - - 0 1", ErrInvalidPawns},
+		{"side to move in check", "4k3/8/8/8/8/8/8/4R1K1 b - - 0 1", nil},
+		{"side not to move in check", "4k3/8/8/8/8/8/8/4R1K1 w - - 0 1", ErrInvalidCheck},
+		{"castling rook missing", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", ErrInvalidCastling},
+		{"castling king moved", "4k3/8/8/8/8/8/4K3/R6R w Q - 0 1", ErrInvalidCastling},
+		{"chess960 castling", "4k3/8/8/8/8/8/8/R4K1R w Q - 0 1", nil},
+		{"chess960 castling rook missing", "4k3/8/8/8/8/8/8/1K5R w C - 0 1", ErrInvalidCastling},
+		{"black castling rook missing", "r3k3/8/8/8/8/8/8/4K3 w k - 0 1", ErrInvalidCastling},
+		{"en passant with no pawn", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", ErrInvalidEnPassant},
+		{"en passant for wrong side", "rnbqkbnr/pppp1ppp/8/4p3/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 2", ErrInvalidEnPassant},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			err = pos.Validate()
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+	}{
+		{"unchanged", StartingFEN, StartingFEN},
+		{"drop castling without rooks", "4k3/8/8/8/8/8/8/R3K3 w KQkq - 0 1", "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1"},
+		{"drop castling after king move", "r3k2r/8/8/8/8/8/5K2/R6R b KQkq - 0 1", "r3k2r/8/8/8/8/8/5K2/R6R b kq - 0 1"},
+		{"drop bogus en passant", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", StartingFEN},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pos, err := Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			pos.Normalize()
+			if got := pos.String(); got != tc.want {
+				t.Errorf("Normalize() = %q, want %q", got, tc.want)
+			}
+			if err := pos.Validate(); err != nil {
+				t.Errorf("Validate() after Normalize() error: %v", err)
+			}
+			if pos.Key() != pos.ComputeKey() {
+				t.Errorf("Normalize() left a stale key")
+			}
+		})
+	}
+}
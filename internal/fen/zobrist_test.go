@@ -0,0 +1,109 @@
+package fen
+
+import (
+	"testing"
+)
+
+// Reference keys from the Polyglot book format specification.
+var polyglotKeyTests = []struct {
+	moves string // UCI moves leading to fen from the previous entry
+	fen   string
+	key   uint64
+}{
+	{"", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c},
+	{"e2e4", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+	{"d7d5", "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+	{"e4e5", "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+	{"f7f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+	{"e1e2", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 1 3", 0x652a607ca3f242c1},
+	{"e8f7", "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 2 4", 0x00fdd303c946bdd9},
+}
+
+func TestPolyglotKey(t *testing.T) {
+	pos := StartingPosition()
+	for _, tc := range polyglotKeyTests {
+		if tc.moves != "" {
+			m, err := pos.ParseUCIMove(tc.moves)
+			if err != nil {
+				t.Fatalf("ParseUCIMove(%q) error: %v", tc.moves, err)
+			}
+			pos.MakeMove(m)
+		}
+
+		if got := pos.Key(); got != tc.key {
+			t.Errorf("after %s: incremental key = %#016x, want %#016x", tc.moves, got, tc.key)
+		}
+
+		parsed, err := Parse(tc.fen)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+		}
+		if got := parsed.Key(); got != tc.key {
+			t.Errorf("Parse(%q).Key() = %#016x, want %#016x", tc.fen, got, tc.key)
+		}
+	}
+}
+
+func TestPolyglotKeyEnPassant(t *testing.T) {
+	tests := []struct {
+		fen string
+		key uint64
+	}{
+		// The c3 square is hashed because the b4 pawn can capture en passant
+		{"rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+		{"rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+	}
+
+	for _, tc := range tests {
+		pos, err := Parse(tc.fen)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+		}
+		if got := pos.Key(); got != tc.key {
+			t.Errorf("Parse(%q).Key() = %#016x, want %#016x", tc.fen, got, tc.key)
+		}
+	}
+
+	// An en passant square with no pawn able to capture is not part of the key
+	withEP, _ := Parse("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	withoutEP, _ := Parse("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1")
+	if withEP.Key() != withoutEP.Key() {
+		t.Errorf("unusable en passant square changed the key")
+	}
+}
+
+func TestKeyIncremental(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+
+	var walk func(t *testing.T, pos *Position, depth int)
+	walk = func(t *testing.T, pos *Position, depth int) {
+		if got, want := pos.Key(), pos.ComputeKey(); got != want {
+			t.Fatalf("incremental key %#016x != computed %#016x for %s", got, want, pos)
+		}
+		if depth == 0 {
+			return
+		}
+		for _, m := range pos.LegalMoves() {
+			before := pos.Key()
+			u := pos.MakeMove(m)
+			walk(t, pos, depth-1)
+			pos.UnmakeMove(m, u)
+			if pos.Key() != before {
+				t.Fatalf("key not restored after unmaking %s", m)
+			}
+		}
+	}
+
+	for _, f := range fens {
+		pos, err := Parse(f)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", f, err)
+		}
+		walk(t, pos, 3)
+	}
+}
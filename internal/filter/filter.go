@@ -0,0 +1,348 @@
+// Package filter selects games from PGN collections by tags, result, length,
+// ECO code and positions reached, in the manner of pgn-extract.
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"rungine/internal/pgn"
+)
+
+var ErrInvalidCriterion = errors.New("invalid filter criterion")
+
+// Op compares a tag value with a criterion's value.
+type Op string
+
+const (
+	OpEqual        Op = "="
+	OpNotEqual     Op = "!="
+	OpLess         Op = "<"
+	OpLessEqual    Op = "<="
+	OpGreater      Op = ">"
+	OpGreaterEqual Op = ">="
+)
+
+// ops lists the operators with two-character ones first, so that they are found before their prefixes.
+var ops = []Op{OpNotEqual, OpLessEqual, OpGreaterEqual, OpEqual, OpLess, OpGreater}
+
+// TagCriterion matches a tag value.
+//
+// With = and != the value is a pattern where * matches any text and ? any
+// character, compared without regard to case; a missing tag counts as empty.
+// The other operators compare numbers, such as Elo ratings, numerically and
+// other values, such as dates, over the length of the criterion's value only,
+// so that "Date>2015" selects games from 2016 on. A missing tag never matches them.
+type TagCriterion struct {
+	Tag   string
+	Op    Op
+	Value string
+}
+
+// ParseTagCriterion parses a criterion like "Black=Carlsen*" or "WhiteElo>=2500".
+func ParseTagCriterion(s string) (TagCriterion, error) {
+	i := strings.IndexAny(s, "=!<>")
+	if tag := strings.TrimSpace(s[:max(i, 0)]); i > 0 && tag != "" {
+		for _, op := range ops {
+			if value, ok := strings.CutPrefix(s[i:], string(op)); ok {
+				return TagCriterion{Tag: tag, Op: op, Value: strings.TrimSpace(value)}, nil
+			}
+		}
+	}
+	return TagCriterion{}, fmt.Errorf("%w: tag criterion %q", ErrInvalidCriterion, s)
+}
+
+// Match returns true if the game's tags satisfy the criterion.
+func (c TagCriterion) Match(tags map[string]string) bool {
+	value, ok := tags[c.Tag]
+	switch c.Op {
+	case OpEqual:
+		return matchPattern(c.Value, value)
+	case OpNotEqual:
+		return !matchPattern(c.Value, value)
+	}
+	if !ok {
+		return false
+	}
+
+	var cmp int
+	x, xerr := strconv.ParseFloat(value, 64)
+	y, yerr := strconv.ParseFloat(c.Value, 64)
+	switch {
+	case xerr == nil && yerr == nil:
+		cmp = compareFloat(x, y)
+	case yerr == nil && (value == "" || value[0] < '0' || value[0] > '9'):
+		// Unknown value, like WhiteElo "?" or Date "????.??.??"
+		return false
+	default:
+		cmp = strings.Compare(value[:min(len(value), len(c.Value))], c.Value)
+	}
+
+	switch c.Op {
+	case OpLess:
+		return cmp < 0
+	case OpLessEqual:
+		return cmp <= 0
+	case OpGreater:
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// matchPattern reports whether s matches a pattern with * and ? wildcards, ignoring case.
+func matchPattern(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+
+	// Backtrack to the last * when the rest does not match
+	starP, starS := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starS = p, i
+			p++
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]):
+			if pattern[p] == '?' {
+				_, size := utf8.DecodeRuneInString(s[i:])
+				i += size
+			} else {
+				i++
+			}
+			p++
+		case starP >= 0:
+			_, size := utf8.DecodeRuneInString(s[starS:])
+			starS += size
+			p, i = starP+1, starS
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// ECORange is an inclusive range of ECO codes, like B90 to B99.
+type ECORange struct {
+	From string
+	To   string
+}
+
+// ParseECORange parses a range like "B90-B99", or a single code like "C42".
+func ParseECORange(s string) (ECORange, error) {
+	from, to, ok := strings.Cut(strings.ToUpper(strings.TrimSpace(s)), "-")
+	if !ok {
+		to = from
+	}
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !isECO(from) || !isECO(to) || from > to {
+		return ECORange{}, fmt.Errorf("%w: ECO range %q", ErrInvalidCriterion, s)
+	}
+	return ECORange{From: from, To: to}, nil
+}
+
+// Match returns true if an ECO code, such as a game's ECO tag, is in the range.
+func (r ECORange) Match(eco string) bool {
+	if len(eco) < 3 || !isECO(strings.ToUpper(eco[:3])) {
+		return false
+	}
+	eco = strings.ToUpper(eco[:3])
+	return r.From <= eco && eco <= r.To
+}
+
+func isECO(s string) bool {
+	return len(s) == 3 && s[0] >= 'A' && s[0] <= 'E' && s[1] >= '0' && s[1] <= '9' && s[2] >= '0' && s[2] <= '9'
+}
+
+// Filter selects the games that meet all of its criteria. Within a list of
+// criteria, such as Results, any one of them is enough. A zero Filter matches every game.
+type Filter struct {
+	Tags      []TagCriterion
+	Results   []string        // Game results, e.g., "1-0"
+	ECO       []ECORange      // Ranges of the ECO tag
+	MinPly    int             // Minimum main line length in plies; 0 for no minimum
+	MaxPly    int             // Maximum main line length in plies; 0 for no maximum
+	Positions []PositionMatch // Positions one of which the main line must reach
+	Material  []Material      // Material balances one of which the main line must reach
+}
+
+// Options describes a filter in text form, as given on a command line or by the frontend.
+type Options struct {
+	Tags      []string `json:"tags"`      // e.g., "Black=Carlsen*", "Date>2015"
+	Results   []string `json:"results"`   // e.g., "1-0"
+	ECO       []string `json:"eco"`       // e.g., "B90-B99"
+	MinPly    int      `json:"minPly"`    // 0 for no minimum
+	MaxPly    int      `json:"maxPly"`    // 0 for no maximum
+	Positions []string `json:"positions"` // FEN, or just the piece placement for either side to move
+	Material  []string `json:"material"`  // Signatures like "KRPKR"
+}
+
+// New creates a filter from its text form.
+func New(opts Options) (*Filter, error) {
+	f := &Filter{MinPly: opts.MinPly, MaxPly: opts.MaxPly}
+	for _, s := range opts.Tags {
+		c, err := ParseTagCriterion(s)
+		if err != nil {
+			return nil, err
+		}
+		f.Tags = append(f.Tags, c)
+	}
+	for _, s := range opts.Results {
+		switch s {
+		case pgn.ResultWhiteWins, pgn.ResultBlackWins, pgn.ResultDraw, pgn.ResultOngoing:
+			f.Results = append(f.Results, s)
+		default:
+			return nil, fmt.Errorf("%w: result %q", ErrInvalidCriterion, s)
+		}
+	}
+	for _, s := range opts.ECO {
+		r, err := ParseECORange(s)
+		if err != nil {
+			return nil, err
+		}
+		f.ECO = append(f.ECO, r)
+	}
+	for _, s := range opts.Positions {
+		p, err := ParsePosition(s)
+		if err != nil {
+			return nil, err
+		}
+		f.Positions = append(f.Positions, p)
+	}
+	for _, s := range opts.Material {
+		m, err := ParseMaterial(s)
+		if err != nil {
+			return nil, err
+		}
+		f.Material = append(f.Material, m)
+	}
+	if f.MinPly < 0 || f.MaxPly < 0 || (f.MaxPly > 0 && f.MinPly > f.MaxPly) {
+		return nil, fmt.Errorf("%w: ply range %d-%d", ErrInvalidCriterion, f.MinPly, f.MaxPly)
+	}
+	return f, nil
+}
+
+// Match returns true if the game meets the filter's criteria.
+// A game with an illegal move only reaches the positions before it.
+func (f *Filter) Match(g *pgn.Game) bool {
+	for _, c := range f.Tags {
+		if !c.Match(g.Tags) {
+			return false
+		}
+	}
+	if len(f.Results) > 0 && !slices.Contains(f.Results, g.Result) {
+		return false
+	}
+	if len(f.ECO) > 0 && !slices.ContainsFunc(f.ECO, func(r ECORange) bool { return r.Match(g.Tags["ECO"]) }) {
+		return false
+	}
+
+	moves := g.MainLine()
+	if len(moves) < f.MinPly || (f.MaxPly > 0 && len(moves) > f.MaxPly) {
+		return false
+	}
+	if len(f.Positions) == 0 && len(f.Material) == 0 {
+		return true
+	}
+	return f.matchPositions(g, moves)
+}
+
+// matchPositions plays the main line and returns true if it reaches one of the
+// positions and one of the material balances asked for.
+func (f *Filter) matchPositions(g *pgn.Game, moves []string) bool {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return false
+	}
+
+	foundPos, foundMat := len(f.Positions) == 0, len(f.Material) == 0
+	for i := 0; ; i++ {
+		if !foundPos {
+			foundPos = slices.ContainsFunc(f.Positions, func(p PositionMatch) bool { return p.Match(pos) })
+		}
+		if !foundMat {
+			mat := MaterialOf(pos)
+			foundMat = slices.Contains(f.Material, mat)
+		}
+		if (foundPos && foundMat) || i == len(moves) {
+			return foundPos && foundMat
+		}
+
+		m, err := pos.ParseSAN(moves[i])
+		if err != nil {
+			return false
+		}
+		pos.MakeMove(m)
+	}
+}
+
+// Stats counts the games a filter has gone through.
+type Stats struct {
+	Games   int `json:"games"`   // Games read, including malformed ones
+	Matched int `json:"matched"` // Games written
+	Errors  int `json:"errors"`  // Malformed games, which are skipped
+}
+
+// progressInterval is how many games go by between progress reports.
+const progressInterval = 1000
+
+// Run reads the games of a PGN stream and writes those that match to w in PGN
+// export format. Malformed games are skipped. If progress is not nil, it is called
+// every thousand games and at the end. Run stops early if the context is cancelled.
+// The games matched so far are written out on every return.
+func (f *Filter) Run(ctx context.Context, r io.Reader, w io.Writer, progress func(Stats)) (stats Stats, err error) {
+	out := pgn.NewWriter(w)
+	defer func() {
+		if ferr := out.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+
+	for game, err := range pgn.NewReader(r).GamesParallel(0) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return stats, ctxErr
+		}
+
+		stats.Games++
+		var perr *pgn.ParseError
+		switch {
+		case errors.As(err, &perr):
+			stats.Errors++
+		case err != nil:
+			return stats, err
+		case f.Match(game):
+			stats.Matched++
+			if err := out.Write(game); err != nil {
+				return stats, err
+			}
+		}
+
+		if progress != nil && stats.Games%progressInterval == 0 {
+			progress(stats)
+		}
+	}
+
+	if progress != nil {
+		progress(stats)
+	}
+	return stats, nil
+}
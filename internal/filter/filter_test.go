@@ -0,0 +1,237 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"rungine/internal/fen"
+	"rungine/internal/pgn"
+)
+
+func TestTagCriterion(t *testing.T) {
+	tags := map[string]string{
+		"White":    "Caruana, Fabiano",
+		"Black":    "Carlsen, Magnus",
+		"Date":     "2016.11.28",
+		"WhiteElo": "2827",
+		"BlackElo": "?",
+	}
+
+	tests := []struct {
+		criterion string
+		want      bool
+	}{
+		{"Black=Carlsen*", true},
+		{"Black=carlsen, magnus", true},
+		{"White=Carlsen*", false},
+		{"White=C?ruana*", true},
+		{"White=*Fabiano", true},
+		{"Black!=Carlsen*", false},
+		{"Event=", true},
+		{"Event!=", false},
+		{"Date>2015", true},
+		{"Date>2016", false},
+		{"Date>=2016.11", true},
+		{"Date<2016.11.28", false},
+		{"Date<=2016.11.28", true},
+		{"WhiteElo>=2800", true},
+		{"WhiteElo<2800", false},
+		{"BlackElo>=2800", false},
+		{"BlackElo<2800", false},
+		{"EventDate>2000", false},
+	}
+
+	for _, tc := range tests {
+		c, err := ParseTagCriterion(tc.criterion)
+		if err != nil {
+			t.Errorf("ParseTagCriterion(%q) error: %v", tc.criterion, err)
+			continue
+		}
+		if got := c.Match(tags); got != tc.want {
+			t.Errorf("%q.Match() = %v, want %v", tc.criterion, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "White", "=Carlsen", " >= 2500"} {
+		if _, err := ParseTagCriterion(bad); !errors.Is(err, ErrInvalidCriterion) {
+			t.Errorf("ParseTagCriterion(%q) error = %v, want ErrInvalidCriterion", bad, err)
+		}
+	}
+}
+
+func TestECORange(t *testing.T) {
+	r, err := ParseECORange("b90-B99")
+	if err != nil {
+		t.Fatalf("ParseECORange() error: %v", err)
+	}
+	for eco, want := range map[string]bool{"B90": true, "B99": true, "b95": true, "B89": false, "C00": false, "": false, "B9": false} {
+		if got := r.Match(eco); got != want {
+			t.Errorf("Match(%q) = %v, want %v", eco, got, want)
+		}
+	}
+
+	for _, bad := range []string{"B99-B90", "F00", "B9-B99", "Sicilian"} {
+		if _, err := ParseECORange(bad); !errors.Is(err, ErrInvalidCriterion) {
+			t.Errorf("ParseECORange(%q) error = %v, want ErrInvalidCriterion", bad, err)
+		}
+	}
+}
+
+func TestMaterial(t *testing.T) {
+	pos, err := fen.Parse("8/8/4k3/8/2R5/4K3/4P3/1r6 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := MaterialOf(pos)
+	if got.String() != "KRPKR" {
+		t.Errorf("MaterialOf() = %s, want KRPKR", got)
+	}
+
+	for _, sig := range []string{"KRPKR", "kprkr"} {
+		m, err := ParseMaterial(sig)
+		if err != nil {
+			t.Errorf("ParseMaterial(%q) error: %v", sig, err)
+		} else if m != got {
+			t.Errorf("ParseMaterial(%q) = %s, want KRPKR", sig, m)
+		}
+	}
+	for _, bad := range []string{"", "RKR", "KRP", "KRKRK", "KXK"} {
+		if _, err := ParseMaterial(bad); !errors.Is(err, ErrInvalidCriterion) {
+			t.Errorf("ParseMaterial(%q) error = %v, want ErrInvalidCriterion", bad, err)
+		}
+	}
+}
+
+const filterInput = `[Event "One"]
+[White "Carlsen, Magnus"]
+[Black "Caruana, Fabiano"]
+[Date "2018.11.09"]
+[ECO "B33"]
+[Result "1/2-1/2"]
+
+1. e4 c5 2. Nf3 Nc6 3. Nc3 e5 1/2-1/2
+
+[Event "Two"]
+[White "Anand, Viswanathan"]
+[Black "Carlsen, Magnus"]
+[Date "2014.11.08"]
+[ECO "B91"]
+[Result "0-1"]
+
+1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. g3 e5 0-1
+
+[Event "Broken"]
+
+1. e4 e5 ) *
+
+[Event "Three"]
+[White "Nakamura, Hikaru"]
+[Black "Carlsen, Magnus"]
+[Date "2019.05.20"]
+[ECO "B90"]
+[Result "1-0"]
+
+1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Be3 e5 1-0
+`
+
+func TestFilter(t *testing.T) {
+	najdorf := "rnbqkb1r/1p2pppp/p2p1n2/8/3NP3/2N5/PPP2PPP/R1BQKB1R w KQkq - 0 6"
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"no criteria", Options{}, []string{"One", "Two", "Three"}},
+		{"black player", Options{Tags: []string{"Black=Carlsen*"}}, []string{"Two", "Three"}},
+		{"najdorf after 2015", Options{Tags: []string{"Black=Carlsen*", "Date>2015"}, ECO: []string{"B90-B99"}}, []string{"Three"}},
+		{"result", Options{Results: []string{"1-0", "1/2-1/2"}}, []string{"One", "Three"}},
+		{"plies", Options{MinPly: 7, MaxPly: 12}, []string{"Two", "Three"}},
+		{"short", Options{MaxPly: 6}, []string{"One"}},
+		{"position", Options{Positions: []string{najdorf}}, []string{"Two", "Three"}},
+		{"placement only", Options{Positions: []string{strings.Fields(najdorf)[0]}}, []string{"Two", "Three"}},
+		{"wrong side to move", Options{Positions: []string{strings.Replace(najdorf, " w ", " b ", 1)}}, nil},
+		{"material", Options{Material: []string{"KQRRBBNNPPPPPPPKQRRBBNNPPPPPPP"}}, []string{"Two", "Three"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := New(tc.opts)
+			if err != nil {
+				t.Fatalf("New() error: %v", err)
+			}
+
+			var out bytes.Buffer
+			var progress []Stats
+			stats, err := f.Run(context.Background(), strings.NewReader(filterInput), &out, func(s Stats) {
+				progress = append(progress, s)
+			})
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+
+			var got []string
+			for game, err := range pgn.NewReader(&out).Games() {
+				if err != nil {
+					t.Fatalf("output error: %v", err)
+				}
+				got = append(got, game.Tags[pgn.TagEvent])
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("matched %v, want %v", got, tc.want)
+			}
+
+			want := Stats{Games: 4, Matched: len(tc.want), Errors: 1}
+			if stats != want {
+				t.Errorf("Run() = %+v, want %+v", stats, want)
+			}
+			if len(progress) == 0 || progress[len(progress)-1] != want {
+				t.Errorf("progress = %+v, want to end with %+v", progress, want)
+			}
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Tags: []string{"White"}},
+		{Results: []string{"2-0"}},
+		{ECO: []string{"Z00"}},
+		{MinPly: 10, MaxPly: 5},
+		{Positions: []string{"not a fen"}},
+		{Material: []string{"KK?"}},
+	} {
+		if _, err := New(opts); !errors.Is(err, ErrInvalidCriterion) {
+			t.Errorf("New(%+v) error = %v, want ErrInvalidCriterion", opts, err)
+		}
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestRunFlushesOnError(t *testing.T) {
+	f, err := New(Options{Tags: []string{"Black=Carlsen*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readErr := errors.New("disk on fire")
+	r := io.MultiReader(strings.NewReader(filterInput), failingReader{readErr})
+
+	var out bytes.Buffer
+	stats, err := f.Run(context.Background(), r, &out, nil)
+	if !errors.Is(err, readErr) {
+		t.Fatalf("Run() error = %v, want %v", err, readErr)
+	}
+	if stats.Matched == 0 {
+		t.Fatalf("Run() = %+v, want games matched before the error", stats)
+	}
+	if got := strings.Count(out.String(), "[Event "); got != stats.Matched {
+		t.Errorf("wrote %d games, want the %d matched", got, stats.Matched)
+	}
+}
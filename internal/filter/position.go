@@ -0,0 +1,110 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"rungine/internal/fen"
+)
+
+// PositionMatch matches positions with the same pieces on the same squares
+// and, unless AnySide is set, the same side to move. Castling rights, the en
+// passant square and the move counters are not compared.
+type PositionMatch struct {
+	Board      [64]fen.Piece
+	SideToMove fen.Color
+	AnySide    bool
+}
+
+// ParsePosition parses a position to look for: a FEN, or only its piece placement
+// to match either side to move.
+func ParsePosition(s string) (PositionMatch, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return PositionMatch{}, fmt.Errorf("%w: empty position", ErrInvalidCriterion)
+	}
+
+	anySide := len(fields) == 1
+	if anySide {
+		s = fields[0] + " w - -"
+	}
+	pos, err := fen.Parse(s)
+	if err != nil {
+		return PositionMatch{}, fmt.Errorf("%w: position %q: %w", ErrInvalidCriterion, s, err)
+	}
+	return PositionMatch{Board: pos.Board, SideToMove: pos.SideToMove, AnySide: anySide}, nil
+}
+
+// Match returns true if pos is the position looked for.
+func (m PositionMatch) Match(pos *fen.Position) bool {
+	return pos.Board == m.Board && (m.AnySide || pos.SideToMove == m.SideToMove)
+}
+
+// Material counts each side's pieces, indexed by color and then by piece kind
+// from pawn to king, as fen.WhitePawn to fen.WhiteKing.
+type Material [2][6]int
+
+// signatureOrder is the order pieces are written in a material signature.
+var signatureOrder = []struct {
+	kind int
+	char byte
+}{
+	{5, 'K'}, {4, 'Q'}, {3, 'R'}, {2, 'B'}, {1, 'N'}, {0, 'P'},
+}
+
+// ParseMaterial parses a material signature: each side's pieces, White first,
+// each side starting with its king, like "KRPKR" for rook and pawn against rook.
+// Pieces after the king may come in any order.
+func ParseMaterial(s string) (Material, error) {
+	var m Material
+	sig := strings.ToUpper(strings.TrimSpace(s))
+	if len(sig) == 0 || sig[0] != 'K' {
+		return m, fmt.Errorf("%w: material %q must start with K", ErrInvalidCriterion, s)
+	}
+
+	side := -1
+	for i := 0; i < len(sig); i++ {
+		kind := strings.IndexByte("PNBRQK", sig[i])
+		if kind < 0 {
+			return m, fmt.Errorf("%w: material %q", ErrInvalidCriterion, s)
+		}
+		if kind == 5 {
+			side++
+			if side > 1 {
+				return m, fmt.Errorf("%w: material %q has more than two kings", ErrInvalidCriterion, s)
+			}
+		}
+		m[side][kind]++
+	}
+	if side != 1 {
+		return m, fmt.Errorf("%w: material %q needs both kings", ErrInvalidCriterion, s)
+	}
+	return m, nil
+}
+
+// MaterialOf counts the pieces of a position.
+func MaterialOf(pos *fen.Position) Material {
+	var m Material
+	for _, p := range pos.Board {
+		switch {
+		case p >= fen.WhitePawn && p <= fen.WhiteKing:
+			m[0][p-fen.WhitePawn]++
+		case p >= fen.BlackPawn && p <= fen.BlackKing:
+			m[1][p-fen.BlackPawn]++
+		}
+	}
+	return m
+}
+
+// String returns the material signature, like "KRPKR".
+func (m Material) String() string {
+	var sb strings.Builder
+	for side := range m {
+		for _, o := range signatureOrder {
+			for range m[side][o.kind] {
+				sb.WriteByte(o.char)
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,341 @@
+package pgn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Comment commands embedded in PGN comments, as written by Lichess, Chess.com and ChessBase.
+const (
+	CommandClock   = "clk"  // Remaining clock time after the move
+	CommandElapsed = "emt"  // Time spent on the move
+	CommandEval    = "eval" // Engine evaluation
+	CommandSquares = "csl"  // Colored squares
+	CommandArrows  = "cal"  // Colored arrows
+)
+
+// Eval is an engine evaluation from a [%eval] command, from White's point of view.
+type Eval struct {
+	Centipawns *int // Nil if mate score
+	Mate       *int // Moves to mate, negative if Black mates
+	Depth      int  // Search depth, 0 if not given
+}
+
+// String returns the evaluation as written in a [%eval] command: pawns with two decimals or #n for mate.
+func (e Eval) String() string {
+	var s string
+	switch {
+	case e.Mate != nil:
+		s = "#" + strconv.Itoa(*e.Mate)
+	case e.Centipawns != nil:
+		s = formatPawns(*e.Centipawns)
+	default:
+		return ""
+	}
+	if e.Depth > 0 {
+		s += "," + strconv.Itoa(e.Depth)
+	}
+	return s
+}
+
+// ColoredSquare is a square highlighted with a [%csl] command.
+type ColoredSquare struct {
+	Color  string // "G", "R", "Y" or "B"
+	Square string // e.g., "d4"
+}
+
+// Arrow is an arrow drawn with a [%cal] command.
+type Arrow struct {
+	Color string // "G", "R", "Y" or "B"
+	From  string
+	To    string
+}
+
+// addComment attaches a comment to a node. Known comment commands are parsed into
+// the node's fields; the rest of the text is joined to Comment with a space.
+func (n *MoveNode) addComment(text string, line bool) {
+	raw := text
+	if n.rawComment != "" {
+		raw = n.rawComment + " " + raw
+	}
+	defer func() {
+		n.rawComment, n.parsedComment = raw, n.fullComment()
+	}()
+
+	text = n.parseCommands(text)
+	if text == "" {
+		return
+	}
+	if n.Comment != "" {
+		text = n.Comment + " " + text
+	}
+	n.Comment = text
+	n.LineComment = line
+}
+
+// parseCommands sets the node's fields from the known commands in a comment
+// and returns the comment without them. Unknown or malformed commands are left in the text.
+func (n *MoveNode) parseCommands(text string) string {
+	if !strings.Contains(text, "[%") {
+		return text
+	}
+
+	var sb strings.Builder
+	removed := false
+	rest := text
+	for {
+		i := strings.Index(rest, "[%")
+		if i < 0 {
+			break
+		}
+		name, arg, size, ok := scanCommand(rest[i:])
+		if !ok {
+			sb.WriteString(rest[:i+2])
+			rest = rest[i+2:]
+			continue
+		}
+		sb.WriteString(rest[:i])
+		if n.setCommand(name, strings.TrimSpace(arg)) {
+			removed = true
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteString(rest[i : i+size])
+		}
+		rest = rest[i+size:]
+	}
+	if !removed {
+		return text
+	}
+	sb.WriteString(rest)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// scanCommand splits a command like "[%clk 0:03:12]" at the start of s into its name
+// and argument, and returns its length. The name is one or more word characters and
+// is followed by whitespace; the argument runs to the first "]".
+func scanCommand(s string) (name, arg string, size int, ok bool) {
+	i := 2 // "[%"
+	for i < len(s) && isWordChar(s[i]) {
+		i++
+	}
+	name = s[2:i]
+	if name == "" || i == len(s) || !isCommandSpace(s[i]) {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[i:], ']')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	return name, s[i : i+end], i + end + 1, true
+}
+
+func isWordChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+func isCommandSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// setCommand parses one command into the node's fields and returns false if it is not recognized.
+func (n *MoveNode) setCommand(name, arg string) bool {
+	switch name {
+	case CommandClock, CommandElapsed:
+		d, ok := parseClock(arg)
+		if !ok {
+			return false
+		}
+		if name == CommandClock {
+			n.Clock = &d
+		} else {
+			n.Elapsed = &d
+		}
+	case CommandEval:
+		e, ok := parseEval(arg)
+		if !ok {
+			return false
+		}
+		n.Eval = &e
+	case CommandSquares:
+		squares, ok := parseSquares(arg)
+		if !ok {
+			return false
+		}
+		n.Squares = append(n.Squares, squares...)
+	case CommandArrows:
+		arrows, ok := parseArrows(arg)
+		if !ok {
+			return false
+		}
+		n.Arrows = append(n.Arrows, arrows...)
+	default:
+		return false
+	}
+	return true
+}
+
+// commands returns the node's fields as comment commands, in the order Lichess writes them.
+func (n *MoveNode) commands() []string {
+	var cmds []string
+	if n.Eval != nil {
+		if s := n.Eval.String(); s != "" {
+			cmds = append(cmds, "[%eval "+s+"]")
+		}
+	}
+	if n.Clock != nil {
+		cmds = append(cmds, "[%clk "+formatClock(*n.Clock)+"]")
+	}
+	if n.Elapsed != nil {
+		cmds = append(cmds, "[%emt "+formatClock(*n.Elapsed)+"]")
+	}
+	if len(n.Squares) > 0 {
+		parts := make([]string, len(n.Squares))
+		for i, sq := range n.Squares {
+			parts[i] = sq.Color + sq.Square
+		}
+		cmds = append(cmds, "[%csl "+strings.Join(parts, ",")+"]")
+	}
+	if len(n.Arrows) > 0 {
+		parts := make([]string, len(n.Arrows))
+		for i, a := range n.Arrows {
+			parts[i] = a.Color + a.From + a.To
+		}
+		cmds = append(cmds, "[%cal "+strings.Join(parts, ",")+"]")
+	}
+	return cmds
+}
+
+// exportComment returns the comment as written to PGN: as it was read, with
+// the commands where they were, unless the text or a command has been changed
+// since, and otherwise as fullComment writes it.
+func (n *MoveNode) exportComment() string {
+	full := n.fullComment()
+	if n.rawComment != "" && full == n.parsedComment {
+		return n.rawComment
+	}
+	return full
+}
+
+// fullComment returns the comment with the commands followed by the free text.
+func (n *MoveNode) fullComment() string {
+	parts := n.commands()
+	if n.Comment != "" {
+		parts = append(parts, n.Comment)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseClock parses a clock time like "1:02:03", "0:00:59.5" or "2:30".
+func parseClock(s string) (time.Duration, bool) {
+	hours, rest := "0", s
+	if strings.Count(s, ":") == 2 {
+		hours, rest, _ = strings.Cut(s, ":")
+	}
+	mins, secs, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, false
+	}
+
+	h, herr := strconv.Atoi(hours)
+	m, merr := strconv.Atoi(mins)
+	sec, serr := strconv.ParseFloat(secs, 64)
+	if herr != nil || merr != nil || serr != nil || h < 0 || m < 0 || sec < 0 || sec >= 60 {
+		return 0, false
+	}
+	d := time.Duration(sec * float64(time.Second)).Round(time.Millisecond)
+	return d + time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, true
+}
+
+// formatClock writes a duration as H:MM:SS, with a fraction only if there is one.
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+	ms := (d % time.Second) / time.Millisecond
+
+	out := fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	if ms > 0 {
+		out += strings.TrimRight(fmt.Sprintf(".%03d", ms), "0")
+	}
+	return out
+}
+
+// parseEval parses an evaluation like "0.35", "-1.2", "#-3" or "0.35,20".
+func parseEval(s string) (Eval, bool) {
+	var e Eval
+	value, depth, hasDepth := strings.Cut(s, ",")
+	if hasDepth {
+		d, err := strconv.Atoi(strings.TrimSpace(depth))
+		if err != nil || d < 0 {
+			return e, false
+		}
+		e.Depth = d
+	}
+
+	value = strings.TrimSpace(value)
+	if mate, ok := strings.CutPrefix(value, "#"); ok {
+		n, err := strconv.Atoi(mate)
+		if err != nil {
+			return e, false
+		}
+		e.Mate = &n
+		return e, true
+	}
+
+	pawns, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return e, false
+	}
+	cp := int(pawns*100 + 0.5)
+	if pawns < 0 {
+		cp = int(pawns*100 - 0.5)
+	}
+	e.Centipawns = &cp
+	return e, true
+}
+
+// formatPawns writes centipawns as pawns with two decimals.
+func formatPawns(cp int) string {
+	sign := ""
+	if cp < 0 {
+		sign, cp = "-", -cp
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cp/100, cp%100)
+}
+
+// parseSquares parses a [%csl] argument like "Gd4,Re5".
+func parseSquares(s string) ([]ColoredSquare, bool) {
+	var squares []ColoredSquare
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) != 3 || !isShapeColor(item[0]) || !isSquareName(item[1:3]) {
+			return nil, false
+		}
+		squares = append(squares, ColoredSquare{Color: item[:1], Square: item[1:3]})
+	}
+	return squares, true
+}
+
+// parseArrows parses a [%cal] argument like "Ge2e4,Rd8d1".
+func parseArrows(s string) ([]Arrow, bool) {
+	var arrows []Arrow
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) != 5 || !isShapeColor(item[0]) || !isSquareName(item[1:3]) || !isSquareName(item[3:5]) {
+			return nil, false
+		}
+		arrows = append(arrows, Arrow{Color: item[:1], From: item[1:3], To: item[3:5]})
+	}
+	return arrows, true
+}
+
+func isShapeColor(c byte) bool {
+	return c == 'G' || c == 'R' || c == 'Y' || c == 'B'
+}
+
+func isSquareName(s string) bool {
+	return len(s) == 2 && s[0] >= 'a' && s[0] <= 'h' && s[1] >= '1' && s[1] <= '8'
+}
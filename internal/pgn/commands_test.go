@@ -0,0 +1,138 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommentCommands(t *testing.T) {
+	input := `[Event "Commands"]
+
+1. e4 {[%eval 0.17] [%clk 0:03:00]} e5 {[%eval -0.2,24] [%clk 0:02:59.5]}
+2. Nf3 {Develops [%csl Gd4,Re5][%cal Gf3e5,Rd8h4] the knight} Nc6 {[%emt 0:00:07] [%eval #-3] [%foo bar]} *`
+
+	game, err := NewParser(strings.NewReader(input)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+
+	e4 := game.Moves.Next
+	e5 := e4.Next
+	nf3 := e5.Next
+	nc6 := nf3.Next
+
+	if e4.Clock == nil || *e4.Clock != 3*time.Minute {
+		t.Errorf("e4 clock = %v, want 3m", e4.Clock)
+	}
+	if e4.Eval == nil || e4.Eval.Centipawns == nil || *e4.Eval.Centipawns != 17 {
+		t.Errorf("e4 eval = %+v, want 17cp", e4.Eval)
+	}
+	if e4.Comment != "" {
+		t.Errorf("e4 comment = %q, want empty", e4.Comment)
+	}
+
+	if e5.Clock == nil || *e5.Clock != 2*time.Minute+59500*time.Millisecond {
+		t.Errorf("e5 clock = %v, want 2m59.5s", e5.Clock)
+	}
+	if e5.Eval == nil || *e5.Eval.Centipawns != -20 || e5.Eval.Depth != 24 {
+		t.Errorf("e5 eval = %+v, want -20cp at depth 24", e5.Eval)
+	}
+
+	if nf3.Comment != "Develops the knight" {
+		t.Errorf("Nf3 comment = %q, want %q", nf3.Comment, "Develops the knight")
+	}
+	if len(nf3.Squares) != 2 || nf3.Squares[1] != (ColoredSquare{Color: "R", Square: "e5"}) {
+		t.Errorf("Nf3 squares = %v", nf3.Squares)
+	}
+	if len(nf3.Arrows) != 2 || nf3.Arrows[1] != (Arrow{Color: "R", From: "d8", To: "h4"}) {
+		t.Errorf("Nf3 arrows = %v", nf3.Arrows)
+	}
+
+	if nc6.Elapsed == nil || *nc6.Elapsed != 7*time.Second {
+		t.Errorf("Nc6 elapsed = %v, want 7s", nc6.Elapsed)
+	}
+	if nc6.Eval == nil || nc6.Eval.Mate == nil || *nc6.Eval.Mate != -3 {
+		t.Errorf("Nc6 eval = %+v, want mate -3", nc6.Eval)
+	}
+	if nc6.Comment != "[%foo bar]" {
+		t.Errorf("Nc6 comment = %q, unknown command should stay in the text", nc6.Comment)
+	}
+
+	// Comments are written back as they were read
+	want := `1. e4 {[%eval 0.17] [%clk 0:03:00]} 1... e5 {[%eval -0.2,24] [%clk 0:02:59.5]}
+2. Nf3 {Develops [%csl Gd4,Re5][%cal Gf3e5,Rd8h4] the knight} 2... Nc6 {[%emt
+0:00:07] [%eval #-3] [%foo bar]} *
+`
+	if got := game.String(); !strings.HasSuffix(got, "\n\n"+want) {
+		t.Errorf("String() movetext =\n%s\nwant\n%s", got, want)
+	}
+
+	// unless edited, when the commands come first in the usual order
+	e5.Eval.Depth = 25
+	nf3.Comment = "Develops the knight!"
+	want = `1. e4 {[%eval 0.17] [%clk 0:03:00]} 1... e5 {[%eval -0.20,25] [%clk 0:02:59.5]}
+2. Nf3 {[%csl Gd4,Re5] [%cal Gf3e5,Rd8h4] Develops the knight!} 2... Nc6 {[%emt
+0:00:07] [%eval #-3] [%foo bar]} *
+`
+	if got := game.String(); !strings.HasSuffix(got, "\n\n"+want) {
+		t.Errorf("String() movetext after edits =\n%s\nwant\n%s", got, want)
+	}
+	again, err := NewParser(strings.NewReader(game.String())).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() of export error: %v", err)
+	}
+	if again.String() != game.String() {
+		t.Errorf("export does not round-trip:\n%s", again.String())
+	}
+}
+
+func TestCommentRoundTrip(t *testing.T) {
+	for _, comment := range []string{
+		"Good move [%clk 0:01:00]",
+		"[%clk 0:01:00] Good move",
+		"Threat [%cal Rd8d1] and [%eval 1.5] more",
+		"[%eval 0.30] [%clk 0:00:05]",
+	} {
+		input := "1. e4 {" + comment + "} *"
+		game, err := NewParser(strings.NewReader(input)).ParseGame()
+		if err != nil {
+			t.Fatalf("ParseGame(%q) error: %v", input, err)
+		}
+		if game.Moves.Next.Clock == nil && game.Moves.Next.Eval == nil {
+			t.Errorf("%q: no command parsed", comment)
+		}
+		if got := game.String(); !strings.HasSuffix(got, "\n\n"+input+"\n") {
+			t.Errorf("String() =\n%s\nwant movetext %s", got, input)
+		}
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"0:03:12", 3*time.Minute + 12*time.Second, true},
+		{"1:30:00", 90 * time.Minute, true},
+		{"0:00:09.8", 9800 * time.Millisecond, true},
+		{"5:07", 5*time.Minute + 7*time.Second, true},
+		{"0:61:00", 61 * time.Minute, true},
+		{"0:00:60", 0, false},
+		{"12", 0, false},
+		{"a:b:c", 0, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := parseClock(tc.in)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("parseClock(%q) = %v, %v, want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+		if ok && tc.in != "5:07" && tc.in != "0:61:00" {
+			if s := formatClock(got); s != tc.in {
+				t.Errorf("formatClock(%v) = %q, want %q", got, s, tc.in)
+			}
+		}
+	}
+}
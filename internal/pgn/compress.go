@@ -0,0 +1,205 @@
+package pgn
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression format of a PGN file.
+type Compression string
+
+const (
+	CompressionNone  Compression = ""
+	CompressionGzip  Compression = "gzip"
+	CompressionBzip2 Compression = "bzip2"
+	CompressionZstd  Compression = "zstd"
+	CompressionZip   Compression = "zip"
+)
+
+// Magic bytes at the start of each compression format.
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicBzip2 = []byte("BZh")
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicZip   = []byte("PK\x03\x04")
+)
+
+var (
+	ErrZipEntries = errors.New("zip archive must contain exactly one file")
+	ErrCompressed = errors.New("file is compressed")
+)
+
+// File is a PGN file opened for reading, decompressed on the fly if needed.
+type File struct {
+	r           io.Reader
+	closers     []func() error
+	compression Compression
+	consumed    atomic.Int64 // Bytes read from the file on disk
+	size        int64
+}
+
+// Open opens a PGN file for reading. Gzip, bzip2, zstd and single-file zip
+// archives are detected from their magic bytes, whatever the file name, and
+// decompressed as they are read.
+func Open(path string) (*File, error) {
+	osf, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := osf.Stat()
+	if err != nil {
+		osf.Close()
+		return nil, err
+	}
+
+	f := &File{size: info.Size(), closers: []func() error{osf.Close}}
+	if err := f.init(osf); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// init detects the compression of osf and sets up the decompressor.
+func (f *File) init(osf *os.File) error {
+	br := bufio.NewReaderSize(&countingReader{r: osf, n: &f.consumed}, 64*1024)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	f.compression = detectCompression(magic)
+
+	switch f.compression {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		f.r = zr
+		f.closers = append(f.closers, zr.Close)
+	case CompressionBzip2:
+		f.r = bzip2.NewReader(br)
+	case CompressionZstd:
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return err
+		}
+		f.r = zr
+		f.closers = append(f.closers, func() error { zr.Close(); return nil })
+	case CompressionZip:
+		return f.openZipEntry(osf)
+	default:
+		f.r = br
+	}
+	return nil
+}
+
+// openZipEntry reads the only file in a zip archive. Central directory reads
+// count towards progress too, but they are small next to the entry itself.
+func (f *File) openZipEntry(osf *os.File) error {
+	zr, err := zip.NewReader(&countingReaderAt{r: osf, n: &f.consumed}, f.size)
+	if err != nil {
+		return err
+	}
+
+	var entry *zip.File
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		if entry != nil {
+			return ErrZipEntries
+		}
+		entry = zf
+	}
+	if entry == nil {
+		return ErrZipEntries
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	f.r = rc
+	f.closers = append(f.closers, rc.Close)
+	return nil
+}
+
+// detectCompression identifies a compression format from the first bytes of a file.
+func detectCompression(magic []byte) Compression {
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		return CompressionGzip
+	case bytes.HasPrefix(magic, magicBzip2):
+		return CompressionBzip2
+	case bytes.HasPrefix(magic, magicZstd):
+		return CompressionZstd
+	case bytes.HasPrefix(magic, magicZip):
+		return CompressionZip
+	default:
+		return CompressionNone
+	}
+}
+
+// Read reads decompressed PGN text.
+func (f *File) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// Close closes the decompressor and the file.
+func (f *File) Close() error {
+	var err error
+	for i := len(f.closers) - 1; i >= 0; i-- {
+		if cerr := f.closers[i](); err == nil {
+			err = cerr
+		}
+	}
+	f.closers = nil
+	return err
+}
+
+// Compression returns the detected compression format.
+func (f *File) Compression() Compression {
+	return f.compression
+}
+
+// Progress returns how many bytes of the file on disk have been consumed and the
+// file's size, for showing progress through a compressed file. It may be called
+// from another goroutine while reading.
+func (f *File) Progress() (consumed, total int64) {
+	return f.consumed.Load(), f.size
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// countingReaderAt counts the bytes read through it.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n *atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,117 @@
+package pgn
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const compressText = "[Event \"Bzip2\"]\n\n1. e4 e5 *\n"
+
+// compressBzip2 is compressText compressed with bzip2, which the standard library cannot write.
+var compressBzip2 = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x59, 0x1f, 0x25, 0x7a, 0x00, 0x00,
+	0x08, 0xdf, 0x80, 0x00, 0x10, 0x50, 0x11, 0x36, 0x00, 0x12, 0x00, 0x00, 0x0a, 0x02, 0x21, 0x45,
+	0x10, 0x20, 0x00, 0x31, 0x4c, 0x26, 0x9a, 0x03, 0x4c, 0x42, 0x9a, 0x34, 0xc8, 0xd3, 0x46, 0x98,
+	0x8a, 0x02, 0xe8, 0x68, 0x68, 0x09, 0x97, 0x6a, 0x2b, 0x0d, 0x8a, 0x91, 0x25, 0x13, 0x33, 0xf1,
+	0x77, 0x24, 0x53, 0x85, 0x09, 0x05, 0x91, 0xf2, 0x57, 0xa0,
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression Compression
+		data        func(t *testing.T) []byte
+	}{
+		{"plain.pgn", CompressionNone, func(*testing.T) []byte { return []byte(compressText) }},
+		{"games.pgn.gz", CompressionGzip, func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write([]byte(compressText))
+			zw.Close()
+			return buf.Bytes()
+		}},
+		{"games.pgn.bz2", CompressionBzip2, func(*testing.T) []byte { return compressBzip2 }},
+		{"games.pgn.zst", CompressionZstd, func(t *testing.T) []byte {
+			zw, err := zstd.NewWriter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zw.Close()
+			return zw.EncodeAll([]byte(compressText), nil)
+		}},
+		{"twic.zip", CompressionZip, func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			zw.Create("twic/")
+			w, _ := zw.Create("twic/twic1500.pgn")
+			w.Write([]byte(compressText))
+			zw.Close()
+			return buf.Bytes()
+		}},
+		// Detection does not depend on the file name
+		{"misnamed.pgn", CompressionGzip, func(t *testing.T) []byte {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			zw.Write([]byte(compressText))
+			zw.Close()
+			return buf.Bytes()
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.data(t)
+			path := filepath.Join(t.TempDir(), tc.name)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open() error: %v", err)
+			}
+			defer f.Close()
+
+			if f.Compression() != tc.compression {
+				t.Errorf("Compression() = %q, want %q", f.Compression(), tc.compression)
+			}
+			game, err := NewReader(f).Read()
+			if err != nil {
+				t.Fatalf("Read() error: %v", err)
+			}
+			if game.Tags[TagEvent] != "Bzip2" || len(game.MainLine()) != 2 {
+				t.Errorf("game = %q with %d moves", game.Tags[TagEvent], len(game.MainLine()))
+			}
+
+			if consumed, total := f.Progress(); consumed < int64(len(data))/2 || total != int64(len(data)) {
+				t.Errorf("Progress() = %d, %d, want about %d of %d", consumed, total, len(data), len(data))
+			}
+		})
+	}
+}
+
+func TestOpenZipEntries(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"a.pgn", "b.pgn"} {
+		w, _ := zw.Create(name)
+		io.WriteString(w, compressText)
+	}
+	zw.Close()
+
+	path := filepath.Join(t.TempDir(), "two.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); !errors.Is(err, ErrZipEntries) {
+		t.Errorf("Open() error = %v, want ErrZipEntries", err)
+	}
+}
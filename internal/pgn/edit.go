@@ -0,0 +1,267 @@
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrMainLine    = errors.New("move is on the main line")
+	ErrNoVariation = errors.New("no variation to swap with")
+	ErrRootNode    = errors.New("operation needs a move, not the start of the game")
+	ErrInvalidPath = errors.New("invalid move path")
+)
+
+// The move tree stores alternatives to a node's Next as placeholder nodes in
+// Variations: a placeholder has no move, the same Ply as the branching node,
+// and the variation's first move as its Next. The parser also gives a placeholder
+// Variations of its own for a variation nested right at the start of another one,
+// as in "1. e4 (1. d4 (1. c4) d5)"; those are alternatives at the same branch.
+// The editing operations below read both shapes, write only the first, and keep
+// Parent and Ply consistent.
+
+// isPlaceholder returns true for a variation placeholder. The root also has no move but no parent either.
+func (n *MoveNode) isPlaceholder() bool {
+	return n.Move == "" && n.Parent != nil
+}
+
+// alternatives returns the moves that can follow n: Next first, then the first move of each variation,
+// including those nested in a placeholder, in the order they are written.
+func (n *MoveNode) alternatives() []*MoveNode {
+	var alts []*MoveNode
+	if n.Next != nil {
+		alts = append(alts, n.Next)
+	}
+	for _, v := range n.Variations {
+		alts = append(alts, v.alternatives()...)
+	}
+	return alts
+}
+
+// placeholders returns n's variation placeholders, including nested ones, by their first move.
+func (n *MoveNode) placeholders(m map[*MoveNode]*MoveNode) map[*MoveNode]*MoveNode {
+	for _, v := range n.Variations {
+		if v.Next != nil {
+			m[v.Next] = v
+		}
+		v.placeholders(m)
+	}
+	return m
+}
+
+// branchNode returns the node whose alternatives include a node with parent p.
+func branchNode(p *MoveNode) *MoveNode {
+	for p.isPlaceholder() {
+		p = p.Parent
+	}
+	return p
+}
+
+// setAlternatives makes alts[0] the main continuation of n and the others its variations, in order.
+// Placeholders, with any comment before the variation, move along with their variation;
+// a variation that becomes the main continuation keeps that comment on its first move.
+func (n *MoveNode) setAlternatives(alts []*MoveNode) {
+	placeholders := n.placeholders(make(map[*MoveNode]*MoveNode))
+
+	n.Next, n.Variations = nil, nil
+	for i, alt := range alts {
+		ph := placeholders[alt]
+		if i == 0 {
+			if ph != nil && ph.Comment != "" {
+				alt.Comment = joinComments(ph.Comment, alt.Comment)
+			}
+			n.Next, alt.Parent = alt, n
+			continue
+		}
+		if ph == nil {
+			ph = &MoveNode{Ply: n.Ply}
+		}
+		ph.Parent, ph.Variations = n, nil
+		ph.Next, alt.Parent = alt, ph
+		n.Variations = append(n.Variations, ph)
+	}
+}
+
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// branch returns the nearest node above n where n's line is one of several alternatives,
+// and the index of n's line there (0 for the main continuation).
+// At the start of the game it returns the root even without alternatives; for the root it returns nil.
+func (n *MoveNode) branch() (*MoveNode, int) {
+	for s := n; s.Parent != nil; s = s.Parent {
+		b := s.Parent
+		if b.isPlaceholder() {
+			b = branchNode(b)
+		} else if len(b.Variations) == 0 && b.Parent != nil {
+			continue
+		}
+		return b, slices.Index(b.alternatives(), s)
+	}
+	return nil, -1
+}
+
+// IsMainLine returns true if n is on the main line of the game.
+func (n *MoveNode) IsMainLine() bool {
+	for s := n; s.Parent != nil; s = s.Parent {
+		if s.Parent.isPlaceholder() {
+			return false
+		}
+	}
+	return true
+}
+
+// AddMove adds a move after n and returns its node. If the move already follows n,
+// in the main continuation or a variation, the existing node is returned, even if
+// one of them is written without its check or mate mark;
+// if n already has a different continuation, the move starts a new variation.
+func (n *MoveNode) AddMove(san string) *MoveNode {
+	for _, alt := range n.alternatives() {
+		if trimCheck(alt.Move) == trimCheck(san) {
+			return alt
+		}
+	}
+
+	node := &MoveNode{Move: san, Ply: n.Ply + 1}
+	if n.Next == nil {
+		n.Next, node.Parent = node, n
+		return node
+	}
+
+	ph := &MoveNode{Parent: n, Ply: n.Ply, Next: node}
+	node.Parent = ph
+	n.Variations = append(n.Variations, ph)
+	return node
+}
+
+// trimCheck removes the check or mate mark from a SAN move.
+func trimCheck(san string) string {
+	return strings.TrimRight(san, "+#")
+}
+
+// Promote moves n's line one place up among the alternatives where it branches off.
+// If n's line is already the main continuation there, the enclosing variation is promoted instead.
+func (n *MoveNode) Promote() error {
+	b, i := n.branch()
+	for b != nil && i == 0 {
+		b, i = b.branch()
+	}
+	if b == nil {
+		return ErrMainLine
+	}
+
+	alts := b.alternatives()
+	alts[i-1], alts[i] = alts[i], alts[i-1]
+	b.setAlternatives(alts)
+	return nil
+}
+
+// PromoteToMainLine promotes n's line until it is part of the main line.
+func (n *MoveNode) PromoteToMainLine() error {
+	if n.IsMainLine() {
+		return ErrMainLine
+	}
+	for !n.IsMainLine() {
+		if err := n.Promote(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Demote moves n's line one place down among the alternatives where it branches off,
+// so that a main continuation becomes the first variation.
+func (n *MoveNode) Demote() error {
+	b, i := n.branch()
+	if b == nil {
+		return ErrRootNode
+	}
+
+	alts := b.alternatives()
+	if i+1 >= len(alts) {
+		return ErrNoVariation
+	}
+	alts[i], alts[i+1] = alts[i+1], alts[i]
+	b.setAlternatives(alts)
+	return nil
+}
+
+// Delete removes n and every move after it. If n is a main continuation with variations,
+// the first variation takes its place.
+func (n *MoveNode) Delete() error {
+	if n.Parent == nil {
+		return ErrRootNode
+	}
+	b := branchNode(n.Parent)
+
+	b.setAlternatives(slices.DeleteFunc(b.alternatives(), func(alt *MoveNode) bool {
+		return alt == n
+	}))
+	n.Parent = nil
+	return nil
+}
+
+// DeleteVariation removes the whole variation n is in, from its first move.
+func (n *MoveNode) DeleteVariation() error {
+	s := n
+	for s.Parent != nil && !s.Parent.isPlaceholder() {
+		s = s.Parent
+	}
+	if s.Parent == nil {
+		return ErrMainLine
+	}
+	return s.Delete()
+}
+
+// Truncate removes every move after n, including the variations branching off after it.
+func (n *MoveNode) Truncate() {
+	n.Next, n.Variations = nil, nil
+}
+
+// Moves returns the SAN moves from the start of the game up to and including n.
+func (n *MoveNode) Moves() []string {
+	var moves []string
+	for s := n; s != nil; s = s.Parent {
+		if s.Move != "" {
+			moves = append(moves, s.Move)
+		}
+	}
+	slices.Reverse(moves)
+	return moves
+}
+
+// Path returns the way from the start of the game to n: for each ply, the index of the
+// move taken among the alternatives, with 0 for the main continuation.
+func (n *MoveNode) Path() []int {
+	path := []int{}
+	for s := n; s.Parent != nil; {
+		b := branchNode(s.Parent)
+		path = append(path, slices.Index(b.alternatives(), s))
+		s = b
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// Node returns the node at the end of a path, as returned by MoveNode.Path.
+// An empty path is the start of the game.
+func (g *Game) Node(path []int) (*MoveNode, error) {
+	node := g.Moves
+	if node == nil {
+		return nil, fmt.Errorf("%w: game has no move tree", ErrInvalidPath)
+	}
+	for i, idx := range path {
+		alts := node.alternatives()
+		if idx < 0 || idx >= len(alts) {
+			return nil, fmt.Errorf("%w: no move %d at ply %d", ErrInvalidPath, idx, i+1)
+		}
+		node = alts[idx]
+	}
+	return node, nil
+}
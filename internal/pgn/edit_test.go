@@ -0,0 +1,313 @@
+package pgn
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const editInput = `1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) (1... e6) 2. Nf3 *`
+
+// movetext returns the exported movetext of a game on one line.
+func movetext(g *Game) string {
+	s := g.String()
+	s = s[strings.Index(s, "\n\n")+2:]
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// checkTree verifies Parent and Ply links throughout the tree.
+func checkTree(t *testing.T, n *MoveNode) {
+	t.Helper()
+	for _, alt := range n.alternatives() {
+		if alt.Ply != n.Ply+1 {
+			t.Errorf("%s: Ply = %d, want %d", alt.Move, alt.Ply, n.Ply+1)
+		}
+		if p := alt.Parent; p == nil || branchNode(p) != n || p.Ply != n.Ply && p != n {
+			t.Errorf("%s: bad Parent", alt.Move)
+		}
+		checkTree(t, alt)
+	}
+}
+
+func TestMoveTreeEditing(t *testing.T) {
+	tests := []struct {
+		name string
+		path []int
+		edit func(n *MoveNode) error
+		want string
+		err  error
+	}{
+		{
+			name: "add existing move",
+			path: []int{0},
+			edit: func(n *MoveNode) error {
+				if n.AddMove("c5") != n.Variations[0].Next {
+					return errors.New("did not return existing variation")
+				}
+				return nil
+			},
+			want: editInput,
+		},
+		{
+			name: "add existing move with check mark",
+			path: []int{0, 0, 0},
+			edit: func(n *MoveNode) error {
+				nc6 := n.AddMove("Nc6")
+				if n.AddMove("Nc6+") != nc6 {
+					return errors.New("did not return existing move for one with check mark")
+				}
+				if nc6.AddMove("Bb5+") != nc6.AddMove("Bb5") {
+					return errors.New("did not return existing move with check mark")
+				}
+				return nil
+			},
+			want: "1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) (1... e6) 2. Nf3 Nc6 3. Bb5+ *",
+		},
+		{
+			name: "add new variation",
+			path: []int{0, 0},
+			edit: func(n *MoveNode) error { n.AddMove("Bc4").AddMove("Nf6"); return nil },
+			want: "1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) (1... e6) 2. Nf3 (2. Bc4 Nf6) *",
+		},
+		{
+			name: "add at end of line",
+			path: []int{0, 0, 0},
+			edit: func(n *MoveNode) error { n.AddMove("Nc6"); return nil },
+			want: "1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) (1... e6) 2. Nf3 Nc6 *",
+		},
+		{
+			name: "promote variation",
+			path: []int{0, 1, 0},
+			edit: func(n *MoveNode) error { return n.Promote() },
+			want: "1. e4 c5 (1... e5 2. Nf3) (1... e6) 2. Nf3 (2. c3 d5) 2... d6 *",
+		},
+		{
+			name: "promote second variation",
+			path: []int{0, 2},
+			edit: func(n *MoveNode) error { return n.Promote() },
+			want: "1. e4 e5 (1... e6) (1... c5 2. Nf3 (2. c3 d5) 2... d6) 2. Nf3 *",
+		},
+		{
+			name: "promote nested variation climbs",
+			path: []int{0, 1, 1, 0},
+			edit: func(n *MoveNode) error { return n.Promote() },
+			want: "1. e4 e5 (1... c5 2. c3 (2. Nf3 d6) 2... d5) (1... e6) 2. Nf3 *",
+		},
+		{
+			name: "promote nested variation to main line",
+			path: []int{0, 1, 1, 0},
+			edit: func(n *MoveNode) error { return n.PromoteToMainLine() },
+			want: "1. e4 c5 (1... e5 2. Nf3) (1... e6) 2. c3 (2. Nf3 d6) 2... d5 *",
+		},
+		{
+			name: "promote main line",
+			path: []int{0, 0, 0},
+			edit: func(n *MoveNode) error { return n.Promote() },
+			want: editInput,
+			err:  ErrMainLine,
+		},
+		{
+			name: "demote main line",
+			path: []int{0, 0, 0},
+			edit: func(n *MoveNode) error { return n.Demote() },
+			want: "1. e4 c5 (1... e5 2. Nf3) (1... e6) 2. Nf3 (2. c3 d5) 2... d6 *",
+		},
+		{
+			name: "demote last variation",
+			path: []int{0, 2},
+			edit: func(n *MoveNode) error { return n.Demote() },
+			want: editInput,
+			err:  ErrNoVariation,
+		},
+		{
+			name: "delete variation from inside",
+			path: []int{0, 1, 0, 0},
+			edit: func(n *MoveNode) error { return n.DeleteVariation() },
+			want: "1. e4 e5 (1... e6) 2. Nf3 *",
+		},
+		{
+			name: "delete variation on main line",
+			path: []int{0, 0},
+			edit: func(n *MoveNode) error { return n.DeleteVariation() },
+			want: editInput,
+			err:  ErrMainLine,
+		},
+		{
+			name: "delete main continuation",
+			path: []int{0, 0},
+			edit: func(n *MoveNode) error { return n.Delete() },
+			want: "1. e4 c5 (1... e6) 2. Nf3 (2. c3 d5) 2... d6 *",
+		},
+		{
+			name: "delete rest of variation",
+			path: []int{0, 1, 0, 0},
+			edit: func(n *MoveNode) error { return n.Delete() },
+			want: "1. e4 e5 (1... c5 2. Nf3 (2. c3 d5)) (1... e6) 2. Nf3 *",
+		},
+		{
+			name: "truncate",
+			path: []int{0},
+			edit: func(n *MoveNode) error { n.Truncate(); return nil },
+			want: "1. e4 *",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := NewParser(strings.NewReader(editInput)).ParseGame()
+			if err != nil {
+				t.Fatalf("ParseGame() error: %v", err)
+			}
+			node, err := game.Node(tc.path)
+			if err != nil {
+				t.Fatalf("Node(%v) error: %v", tc.path, err)
+			}
+			if got := node.Path(); !slices.Equal(got, tc.path) {
+				t.Errorf("Path() = %v, want %v", got, tc.path)
+			}
+
+			if err := tc.edit(node); !errors.Is(err, tc.err) {
+				t.Fatalf("edit error = %v, want %v", err, tc.err)
+			}
+			if got := movetext(game); got != tc.want {
+				t.Errorf("after edit:\n got %s\nwant %s", got, tc.want)
+			}
+			checkTree(t, game.Moves)
+		})
+	}
+}
+
+func TestNestedVariationEditing(t *testing.T) {
+	// The variation starting 1. c4 is nested in the one starting 1. d4, right after its first move
+	const input = "1. e4 (1. d4 (1. c4 c5) 1... d5) 1... e5 *"
+
+	tests := []struct {
+		name  string
+		path  []int
+		moves string
+		edit  func(n *MoveNode) error
+		want  string
+	}{
+		{
+			name:  "nested variation",
+			path:  []int{2, 0},
+			moves: "c4 c5",
+			edit:  func(*MoveNode) error { return nil },
+			want:  input,
+		},
+		{
+			name:  "enclosing variation",
+			path:  []int{1, 0},
+			moves: "d4 d5",
+			edit:  func(*MoveNode) error { return nil },
+			want:  input,
+		},
+		{
+			name:  "add existing move",
+			path:  []int{},
+			moves: "",
+			edit: func(n *MoveNode) error {
+				if n.AddMove("c4") != n.Variations[0].Variations[0].Next {
+					return errors.New("did not return existing variation")
+				}
+				return nil
+			},
+			want: input,
+		},
+		{
+			name:  "insert after nested variation",
+			path:  []int{2},
+			moves: "c4",
+			edit:  func(n *MoveNode) error { n.AddMove("Nf6"); return nil },
+			want:  "1. e4 (1. d4 (1. c4 c5 (1... Nf6)) 1... d5) 1... e5 *",
+		},
+		{
+			name:  "promote",
+			path:  []int{2, 0},
+			moves: "c4 c5",
+			edit:  func(n *MoveNode) error { return n.Promote() },
+			want:  "1. e4 (1. c4 c5) (1. d4 d5) 1... e5 *",
+		},
+		{
+			name:  "promote to main line",
+			path:  []int{2, 0},
+			moves: "c4 c5",
+			edit:  func(n *MoveNode) error { return n.PromoteToMainLine() },
+			want:  "1. c4 (1. e4 e5) (1. d4 d5) 1... c5 *",
+		},
+		{
+			name:  "demote enclosing variation",
+			path:  []int{1},
+			moves: "d4",
+			edit:  func(n *MoveNode) error { return n.Demote() },
+			want:  "1. e4 (1. c4 c5) (1. d4 d5) 1... e5 *",
+		},
+		{
+			name:  "delete enclosing variation",
+			path:  []int{1, 0},
+			moves: "d4 d5",
+			edit:  func(n *MoveNode) error { return n.DeleteVariation() },
+			want:  "1. e4 (1. c4 c5) 1... e5 *",
+		},
+		{
+			name:  "delete nested variation",
+			path:  []int{2, 0},
+			moves: "c4 c5",
+			edit:  func(n *MoveNode) error { return n.DeleteVariation() },
+			want:  "1. e4 (1. d4 d5) 1... e5 *",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := NewParser(strings.NewReader(input)).ParseGame()
+			if err != nil {
+				t.Fatalf("ParseGame() error: %v", err)
+			}
+			node, err := game.Node(tc.path)
+			if err != nil {
+				t.Fatalf("Node(%v) error: %v", tc.path, err)
+			}
+			if got := strings.Join(node.Moves(), " "); got != tc.moves {
+				t.Errorf("Moves() = %q, want %q", got, tc.moves)
+			}
+			if got := node.Path(); !slices.Equal(got, tc.path) {
+				t.Errorf("Path() = %v, want %v", got, tc.path)
+			}
+
+			if err := tc.edit(node); err != nil {
+				t.Fatalf("edit error: %v", err)
+			}
+			if got := movetext(game); got != tc.want {
+				t.Errorf("after edit:\n got %s\nwant %s", got, tc.want)
+			}
+			checkTree(t, game.Moves)
+		})
+	}
+}
+
+func TestNodePath(t *testing.T) {
+	game, err := NewParser(strings.NewReader(editInput)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+
+	node, err := game.Node([]int{0, 1, 1, 0})
+	if err != nil {
+		t.Fatalf("Node() error: %v", err)
+	}
+	if got := strings.Join(node.Moves(), " "); got != "e4 c5 c3 d5" {
+		t.Errorf("Moves() = %q, want %q", got, "e4 c5 c3 d5")
+	}
+	if node.IsMainLine() {
+		t.Error("IsMainLine() = true for a variation move")
+	}
+
+	if _, err := game.Node([]int{0, 3}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Node() with bad index error = %v, want ErrInvalidPath", err)
+	}
+	if err := game.Moves.Delete(); !errors.Is(err, ErrRootNode) {
+		t.Errorf("root Delete() error = %v, want ErrRootNode", err)
+	}
+}
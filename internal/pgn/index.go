@@ -0,0 +1,339 @@
+package pgn
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// IndexSuffix is appended to a PGN file's path to name its index sidecar file.
+const IndexSuffix = ".idx"
+
+// indexVersion changes whenever the sidecar format does, so old indexes are rebuilt.
+const indexVersion = 1
+
+// fingerprintSize is how much of the start and end of a file is hashed to detect changes.
+const fingerprintSize = 64 * 1024
+
+// minIndexChunk is the smallest part of a file given to one indexing worker.
+var minIndexChunk int64 = 4 * 1024 * 1024
+
+var (
+	ErrStaleIndex = errors.New("index does not match the PGN file")
+	ErrNoGame     = errors.New("no such game")
+)
+
+// Index records where each game of a PGN file starts and its header tags,
+// so that any game can be read without parsing the ones before it.
+type Index struct {
+	Version int
+	Size    int64     // Size of the PGN file when indexed
+	ModTime time.Time // Modification time of the PGN file when indexed
+	Hash    []byte    // SHA-256 of the start and end of the PGN file
+	Games   []IndexEntry
+
+	path string // PGN file
+}
+
+// IndexEntry locates one game in the PGN file.
+type IndexEntry struct {
+	Offset int64             // Byte offset of the game's first line
+	Length int64             // Bytes up to the next game
+	Line   int               // 1-based line of the game's first line
+	Tags   map[string]string // Header tags; may be incomplete for a malformed game
+}
+
+// BuildIndex scans a PGN file and indexes its games. The file is split into
+// parts at [Event tags and the parts are scanned in parallel by up to workers
+// goroutines; zero or less means one per CPU. Games are split the same way as by Reader.
+// Compressed files cannot be indexed.
+func BuildIndex(path string, workers int) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Offsets into a compressed stream cannot be seeked to
+	magic := make([]byte, 4)
+	n, _ := f.ReadAt(magic, 0)
+	if c := detectCompression(magic[:n]); c != CompressionNone {
+		return nil, fmt.Errorf("%w with %s, decompress it to index it", ErrCompressed, c)
+	}
+
+	ix, err := newIndex(f, path)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds, err := indexChunks(f, ix.Size, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	type part struct {
+		games []IndexEntry
+		lines int
+		err   error
+	}
+	parts := make([]part, len(bounds)-1)
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := &parts[i]
+			p.games, p.lines, p.err = indexChunk(f, bounds[i], bounds[i+1])
+		}()
+	}
+	wg.Wait()
+
+	// Line numbers in each part count from its start
+	lines := 0
+	for _, p := range parts {
+		if p.err != nil {
+			return nil, p.err
+		}
+		for _, e := range p.games {
+			e.Line += lines
+			ix.Games = append(ix.Games, e)
+		}
+		lines += p.lines
+	}
+	return ix, nil
+}
+
+// LoadIndex reads the index sidecar of a PGN file. It returns ErrStaleIndex if the
+// PGN file has changed since it was indexed, and an error satisfying
+// errors.Is(err, fs.ErrNotExist) if there is no index.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.Open(path + IndexSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer data.Close()
+
+	var ix Index
+	if err := gob.NewDecoder(bufio.NewReader(data)).Decode(&ix); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStaleIndex, err)
+	}
+	ix.path = path
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	current, err := newIndex(f, path)
+	if err != nil {
+		return nil, err
+	}
+	if ix.Version != current.Version || ix.Size != current.Size ||
+		!ix.ModTime.Equal(current.ModTime) || !bytes.Equal(ix.Hash, current.Hash) {
+		return nil, ErrStaleIndex
+	}
+	return &ix, nil
+}
+
+// OpenIndex loads the index of a PGN file, or builds and saves it if it is
+// missing or stale. A sidecar that cannot be written is not an error: the
+// index is rebuilt next time.
+func OpenIndex(path string, workers int) (*Index, error) {
+	ix, err := LoadIndex(path)
+	if err == nil {
+		return ix, nil
+	}
+	if !errors.Is(err, ErrStaleIndex) && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	ix, err = BuildIndex(path, workers)
+	if err != nil {
+		return nil, err
+	}
+	ix.Save()
+	return ix, nil
+}
+
+// Save writes the index to the sidecar file next to the PGN file.
+func (ix *Index) Save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(ix.path), filepath.Base(ix.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	err = gob.NewEncoder(w).Encode(ix)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), ix.path+IndexSuffix)
+}
+
+// Path returns the path of the indexed PGN file.
+func (ix *Index) Path() string {
+	return ix.path
+}
+
+// Len returns the number of games.
+func (ix *Index) Len() int {
+	return len(ix.Games)
+}
+
+// Game reads and parses game n, counting from 0. A malformed game is
+// reported as a *ParseError with lines counted from the start of the file.
+func (ix *Index) Game(n int) (*Game, error) {
+	if n < 0 || n >= len(ix.Games) {
+		return nil, fmt.Errorf("%w: game %d of %d", ErrNoGame, n, len(ix.Games))
+	}
+	e := ix.Games[n]
+
+	f, err := os.Open(ix.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := NewReader(io.NewSectionReader(f, e.Offset, e.Length))
+	r.offset, r.line = e.Offset, e.Line
+	return r.Read()
+}
+
+// Filter returns the numbers of the games whose header tags match.
+func (ix *Index) Filter(match func(tags map[string]string) bool) []int {
+	var games []int
+	for i, e := range ix.Games {
+		if match(e.Tags) {
+			games = append(games, i)
+		}
+	}
+	return games
+}
+
+// newIndex returns an empty index describing the current state of a PGN file.
+func newIndex(f *os.File, path string) (*Index, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := fingerprint(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &Index{
+		Version: indexVersion,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hash,
+		path:    path,
+	}, nil
+}
+
+// fingerprint hashes the size and the start and end of a file. Together with the
+// size and modification time, this catches files rewritten in place without reading all of them.
+func fingerprint(f io.ReaderAt, size int64) ([]byte, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", size)
+
+	head := min(size, fingerprintSize)
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, head)); err != nil {
+		return nil, err
+	}
+	if tail := max(head, size-fingerprintSize); tail < size {
+		if _, err := io.Copy(h, io.NewSectionReader(f, tail, size-tail)); err != nil {
+			return nil, err
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+// indexChunks splits a file into at most workers parts, returning their boundaries.
+// Each part after the first starts at an [Event line that does not follow another
+// tag line, where Reader would start a new game too.
+func indexChunks(f io.ReaderAt, size int64, workers int) ([]int64, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	n := max(1, min(int64(workers), size/minIndexChunk))
+
+	bounds := []int64{0}
+	for i := int64(1); i < n; i++ {
+		start, err := nextGameStart(f, size, size*i/n)
+		if err != nil {
+			return nil, err
+		}
+		if start > bounds[len(bounds)-1] && start < size {
+			bounds = append(bounds, start)
+		}
+	}
+	return append(bounds, size), nil
+}
+
+// nextGameStart returns the offset of the first game boundary after pos, or size if there is none.
+func nextGameStart(f io.ReaderAt, size, pos int64) (int64, error) {
+	br := bufio.NewReader(io.NewSectionReader(f, pos, size-pos))
+
+	// The line containing pos is incomplete, so a game cannot start right after it
+	partial, err := br.ReadBytes('\n')
+	offset := pos + int64(len(partial))
+	afterTag := true
+
+	for err == nil {
+		var line []byte
+		line, err = br.ReadBytes('\n')
+		if isEventTag(line) && !afterTag {
+			return offset, nil
+		}
+		afterTag = isTagLine(line)
+		offset += int64(len(line))
+	}
+	if err != io.EOF {
+		return 0, err
+	}
+	return size, nil
+}
+
+// indexChunk indexes the games in [start, end) and returns them with the number of lines read.
+func indexChunk(f io.ReaderAt, start, end int64) ([]IndexEntry, int, error) {
+	r := NewReader(io.NewSectionReader(f, start, end-start))
+	r.offset = start
+
+	var games []IndexEntry
+	for {
+		chunk, err := r.nextChunk()
+		if err == io.EOF {
+			return games, r.line - 1, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		// A malformed tag keeps the ones before it; the error shows when the game is read
+		tags := make(map[string]string)
+		r.parser.reset(chunk)
+		r.parser.parseTags(tags)
+
+		games = append(games, IndexEntry{
+			Offset: r.gameOffset,
+			Length: r.gameEnd - r.gameOffset,
+			Line:   r.gameLine,
+			Tags:   tags,
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeIndexFile writes n games, with a malformed one and a % line, to a temporary PGN file.
+func writeIndexFile(t *testing.T, n int) (string, string) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "[Event \"Game %d\"]\n[White \"Player %d\"]\n[Black \"Player %d\"]\n", i, i%7, (i+1)%7)
+		if i == 5 {
+			sb.WriteString("\n1. e4 e5 ) *\n\n")
+			continue
+		}
+		if i == 9 {
+			sb.WriteString("% comment line\n")
+		}
+		fmt.Fprintf(&sb, "[Result \"*\"]\n\n1. e4 {a comment\n[not a tag] on a new line} e5 2. Nf3 *\n\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "games.pgn")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, sb.String()
+}
+
+func TestBuildIndex(t *testing.T) {
+	path, text := writeIndexFile(t, 40)
+
+	defer func(size int64) { minIndexChunk = size }(minIndexChunk)
+	minIndexChunk = 64
+
+	sequential, err := BuildIndex(path, 1)
+	if err != nil {
+		t.Fatalf("BuildIndex() error: %v", err)
+	}
+	parallel, err := BuildIndex(path, 8)
+	if err != nil {
+		t.Fatalf("BuildIndex() error: %v", err)
+	}
+	if !reflect.DeepEqual(parallel.Games, sequential.Games) {
+		t.Errorf("parallel index differs from sequential index")
+	}
+
+	// Offsets and lines agree with Reader
+	r := NewReader(strings.NewReader(text))
+	for i := range sequential.Games {
+		r.Read()
+		e := parallel.Games[i]
+		if e.Offset != r.Offset() || e.Line != r.Line() {
+			t.Errorf("game %d at offset %d line %d, want offset %d line %d", i, e.Offset, e.Line, r.Offset(), r.Line())
+		}
+	}
+	if _, err := r.Read(); err == nil {
+		t.Errorf("index has %d games, Reader has more", parallel.Len())
+	}
+
+	game, err := parallel.Game(17)
+	if err != nil {
+		t.Fatalf("Game(17) error: %v", err)
+	}
+	if game.Tags[TagEvent] != "Game 17" || len(game.MainLine()) != 3 {
+		t.Errorf("Game(17) = %q with %d moves", game.Tags[TagEvent], len(game.MainLine()))
+	}
+
+	var perr *ParseError
+	if _, err := parallel.Game(5); !errors.As(err, &perr) || perr.Line != parallel.Games[5].Line+4 {
+		t.Errorf("Game(5) error = %v, want *ParseError at line %d", err, parallel.Games[5].Line+4)
+	}
+	if _, err := parallel.Game(40); !errors.Is(err, ErrNoGame) {
+		t.Errorf("Game(40) error = %v, want ErrNoGame", err)
+	}
+
+	got := parallel.Filter(func(tags map[string]string) bool { return tags[TagWhite] == "Player 3" })
+	if want := []int{3, 10, 17, 24, 31, 38}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
+
+func TestIndexSidecar(t *testing.T) {
+	path, text := writeIndexFile(t, 10)
+
+	if _, err := LoadIndex(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("LoadIndex() without sidecar error = %v, want not exist", err)
+	}
+
+	built, err := OpenIndex(path, 0)
+	if err != nil {
+		t.Fatalf("OpenIndex() error: %v", err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex() error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Games, built.Games) {
+		t.Errorf("loaded index differs from built index")
+	}
+
+	// Same size, different content and time
+	changed := strings.Replace(text, "Game 0", "Game X", 1)
+	if err := os.WriteFile(path, []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Hour))
+	if _, err := LoadIndex(path); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("LoadIndex() after change error = %v, want ErrStaleIndex", err)
+	}
+
+	rebuilt, err := OpenIndex(path, 0)
+	if err != nil {
+		t.Fatalf("OpenIndex() error: %v", err)
+	}
+	if rebuilt.Games[0].Tags[TagEvent] != "Game X" {
+		t.Errorf("rebuilt index has Event %q", rebuilt.Games[0].Tags[TagEvent])
+	}
+	if _, err := LoadIndex(path); err != nil {
+		t.Errorf("LoadIndex() after rebuild error: %v", err)
+	}
+}
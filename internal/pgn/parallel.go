@@ -0,0 +1,115 @@
+package pgn
+
+import (
+	"io"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// parallelBatch is the number of games handed to a worker at a time.
+const parallelBatch = 64
+
+// batch is a run of consecutive games parsed by one worker.
+type batch struct {
+	chunks [][]byte
+	lines  []int
+	games  []*Game
+	errs   []error
+	err    error         // I/O error after the batch's games
+	done   chan struct{} // Closed once the games are parsed
+}
+
+// GamesParallel returns an iterator like Games that splits the input into games
+// as Read does and parses them on workers goroutines; zero or less means one per CPU.
+// Games are yielded in input order. The reader reads ahead of the games yielded;
+// if iteration stops early, the games read ahead are lost.
+func (r *Reader) GamesParallel(workers int) iter.Seq2[*Game, error] {
+	return func(yield func(*Game, error) bool) {
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+
+		stop := make(chan struct{})
+		todo := make(chan *batch, workers)
+		ordered := make(chan *batch, 2*workers)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(todo)
+			defer close(ordered)
+			r.splitBatches(ordered, todo, stop)
+		}()
+		defer func() {
+			// The reader must not be read from once iteration is over
+			close(stop)
+			wg.Wait()
+		}()
+
+		for range workers {
+			go func() {
+				p := NewParser(nil)
+				for b := range todo {
+					b.games = make([]*Game, len(b.chunks))
+					b.errs = make([]error, len(b.chunks))
+					for i, chunk := range b.chunks {
+						b.games[i], b.errs[i] = parseChunk(p, chunk, b.lines[i], r.HeadersOnly)
+					}
+					close(b.done)
+				}
+			}()
+		}
+
+		for b := range ordered {
+			<-b.done
+			for i, game := range b.games {
+				if !yield(game, b.errs[i]) {
+					return
+				}
+			}
+			if b.err != nil {
+				yield(nil, b.err)
+				return
+			}
+		}
+	}
+}
+
+// splitBatches reads the input into batches of games, sending each to ordered, to be
+// yielded in turn, and to todo, to be parsed. It returns at the end of the input,
+// after an I/O error or when stop is closed.
+func (r *Reader) splitBatches(ordered, todo chan<- *batch, stop <-chan struct{}) {
+	for {
+		b := &batch{done: make(chan struct{})}
+		for len(b.chunks) < parallelBatch {
+			chunk, err := r.nextChunk()
+			if err != nil {
+				if err != io.EOF {
+					b.err = err
+				}
+				break
+			}
+			b.chunks = append(b.chunks, chunk)
+			b.lines = append(b.lines, r.gameLine)
+		}
+		if len(b.chunks) == 0 && b.err == nil {
+			return
+		}
+
+		select {
+		case ordered <- b:
+		case <-stop:
+			return
+		}
+		select {
+		case todo <- b:
+		case <-stop:
+			return
+		}
+		if len(b.chunks) < parallelBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package pgn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rungine/internal/fen"
+)
+
+// benchmarkFile names a real PGN file, possibly compressed, to benchmark on as well as the samples.
+var benchmarkFile = os.Getenv("PGN_BENCH_FILE")
+
+// benchmarkSamples are small files in the shape of a Lichess database export
+// (clock and eval comments, computer analysis with variations) and a TWIC issue
+// (wrapped movetext, more tags, the odd annotated game).
+var benchmarkSamples = map[string]string{
+	"lichess": "testdata/lichess.pgn",
+	"twic":    "testdata/twic.pgn",
+}
+
+// benchmarkGames is the number of games in each benchmark input, roughly.
+const benchmarkGames = 2000
+
+var (
+	benchmarkOnce sync.Once
+	benchmarkData map[string][]byte
+)
+
+// benchmarkInputs returns the samples repeated up to benchmarkGames games,
+// along with a generated file of long unannotated games.
+func benchmarkInputs(b *testing.B) map[string][]byte {
+	benchmarkOnce.Do(func() {
+		benchmarkData = map[string][]byte{
+			"generated": generatePGN(rand.New(rand.NewSource(1)), benchmarkGames, false),
+		}
+		for name, path := range benchmarkSamples {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			games := 0
+			for _, err := range NewReader(bytes.NewReader(data)).Games() {
+				if err != nil {
+					b.Fatalf("%s: %v", path, err)
+				}
+				games++
+			}
+			benchmarkData[name] = bytes.Repeat(data, max(benchmarkGames/games, 1))
+		}
+		if benchmarkFile != "" {
+			f, err := Open(benchmarkFile)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer f.Close()
+			data, err := io.ReadAll(f)
+			if err != nil {
+				b.Fatal(err)
+			}
+			benchmarkData["file"] = data
+		}
+	})
+	return benchmarkData
+}
+
+// TestBenchmarkSamples checks that the benchmark samples are what they claim to be.
+func TestBenchmarkSamples(t *testing.T) {
+	for name, path := range benchmarkSamples {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			games, variations := 0, 0
+			for g, err := range NewReader(f).Games() {
+				if err != nil {
+					t.Fatalf("game %d: %v", games+1, err)
+				}
+				games++
+				if err := g.Validate(); err != nil {
+					t.Errorf("game %d: %v", games, err)
+				}
+				for n := g.Moves.Next; n != nil; n = n.Next {
+					variations += len(n.Parent.Variations)
+					if name == "lichess" && n.Clock == nil {
+						t.Errorf("game %d: %s has no clock", games, n.Move)
+					}
+				}
+			}
+			if games < 5 || variations == 0 {
+				t.Errorf("%d games with %d variations on their main lines, want more", games, variations)
+			}
+		})
+	}
+}
+
+// generatePGN writes n games of random legal moves.
+func generatePGN(rng *rand.Rand, n int, lichess bool) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < n; i++ {
+		g := NewGame()
+		g.Tags[TagDate] = date.Add(time.Duration(i) * time.Minute).Format("2006.01.02")
+		g.Tags[TagWhite] = fmt.Sprintf("Player%d", rng.Intn(500))
+		g.Tags[TagBlack] = fmt.Sprintf("Player%d", rng.Intn(500))
+		g.Tags["WhiteElo"] = fmt.Sprint(1200 + rng.Intn(1600))
+		g.Tags["BlackElo"] = fmt.Sprint(1200 + rng.Intn(1600))
+		g.Tags["ECO"] = fmt.Sprintf("%c%02d", 'A'+rng.Intn(5), rng.Intn(100))
+		if lichess {
+			g.Tags[TagEvent] = "Rated Blitz game"
+			g.Tags[TagSite] = fmt.Sprintf("https://lichess.org/%08x", rng.Uint32())
+			g.Tags[TagRound] = "-"
+			g.Tags["UTCDate"] = g.Tags[TagDate]
+			g.Tags["UTCTime"] = date.Add(time.Duration(i) * time.Minute).Format("15:04:05")
+			g.Tags["TimeControl"] = "180+0"
+			g.Tags["Termination"] = "Normal"
+			g.Tags["Opening"] = "Sicilian Defense: Najdorf Variation"
+		} else {
+			g.Tags[TagEvent] = "Chess Olympiad"
+			g.Tags[TagSite] = "Budapest HUN"
+			g.Tags[TagRound] = fmt.Sprintf("%d.%d", 1+rng.Intn(11), 1+rng.Intn(80))
+			g.Tags["EventDate"] = "2024.09.11"
+			g.Tags["WhiteFideId"] = fmt.Sprint(1000000 + rng.Intn(9000000))
+			g.Tags["BlackFideId"] = fmt.Sprint(1000000 + rng.Intn(9000000))
+		}
+
+		pos := fen.StartingPosition()
+		clocks := [2]time.Duration{3 * time.Minute, 3 * time.Minute}
+		plies := 40 + rng.Intn(100)
+		for ply := 0; ply < plies; ply++ {
+			moves := pos.LegalMoves()
+			if len(moves) == 0 {
+				break
+			}
+			m := moves[rng.Intn(len(moves))]
+			node := g.AddMove(pos.SAN(m))
+			pos.MakeMove(m)
+			if lichess {
+				side := ply % 2
+				clocks[side] -= time.Duration(rng.Intn(5000)) * time.Millisecond
+				clocks[side] = max(clocks[side], 0).Truncate(time.Second)
+				clock := clocks[side]
+				node.Clock = &clock
+				if i%5 == 0 {
+					node.Eval = &Eval{Centipawns: new(int)}
+					*node.Eval.Centipawns = rng.Intn(400) - 200
+				}
+			}
+		}
+		result := []string{ResultWhiteWins, ResultBlackWins, ResultDraw}[rng.Intn(3)]
+		g.Tags[TagResult], g.Result = result, result
+		w.Write(g)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// parallelInput mixes generated games with the cases of the parser tests and malformed games.
+func parallelInput() string {
+	var sb strings.Builder
+	sb.Write(generatePGN(rand.New(rand.NewSource(2)), 150, true))
+	sb.WriteString(`[Event "Commented Game"]
+[Result "1-0"]
+
+1. e4 {Best by test} e5 2. Nf3 {Developing} Nc6 ; line comment
+3. Bb5 $1 a6?! 4. Ba4 (4. Bxc6 dxc6 (4... bxc6 5. O-O) 5. O-O) 4... Nf6 1-0
+
+[Event "Unmatched"]
+
+1. e4 e5 ) 2. Nf3 *
+
+[Event "Bad tag"]
+[White Carlsen]
+
+1. e4 *
+
+[Event "Unclosed"]
+
+1. e4 (1. d4 d5
+
+`)
+	sb.Write(generatePGN(rand.New(rand.NewSource(3)), 150, false))
+	return sb.String()
+}
+
+type readResult struct {
+	game *Game
+	err  error
+}
+
+func readAll(games func(func(*Game, error) bool)) []readResult {
+	var results []readResult
+	for game, err := range games {
+		results = append(results, readResult{game, err})
+	}
+	return results
+}
+
+func TestGamesParallel(t *testing.T) {
+	input := parallelInput()
+	want := readAll(NewReader(strings.NewReader(input)).Games())
+	if len(want) != 304 {
+		t.Fatalf("sequential read gave %d games, want 304", len(want))
+	}
+
+	for _, workers := range []int{1, 3, 8} {
+		got := readAll(NewReader(strings.NewReader(input)).GamesParallel(workers))
+		if len(got) != len(want) {
+			t.Fatalf("%d workers: %d games, want %d", workers, len(got), len(want))
+		}
+		for i := range want {
+			if !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("%d workers: game %d = %v, %v, want %v, %v", workers, i, got[i].game, got[i].err, want[i].game, want[i].err)
+			}
+		}
+	}
+}
+
+func TestGamesParallelStop(t *testing.T) {
+	r := NewReader(strings.NewReader(parallelInput()))
+	n := 0
+	for range r.GamesParallel(4) {
+		if n++; n == 10 {
+			break
+		}
+	}
+	// The reader is still usable, past the games read ahead
+	if _, err := r.Read(); err != nil && err != io.EOF {
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Read() after stopping = %v", err)
+		}
+	}
+}
+
+func TestReaderHeadersOnly(t *testing.T) {
+	input := parallelInput()
+	full := readAll(NewReader(strings.NewReader(input)).Games())
+
+	r := NewReader(strings.NewReader(input))
+	r.HeadersOnly = true
+	headers := readAll(r.GamesParallel(2))
+	if len(headers) != len(full) {
+		t.Fatalf("%d games, want %d", len(headers), len(full))
+	}
+
+	for i, h := range headers {
+		f := full[i]
+		if f.err != nil {
+			// Movetext errors go unnoticed, tag errors do not
+			if h.err == nil && errors.Is(f.err, ErrInvalidPGN) && strings.Contains(f.err.Error(), "tag") {
+				t.Errorf("game %d: no error, want %v", i, f.err)
+			}
+			continue
+		}
+		if h.err != nil {
+			t.Errorf("game %d: error %v", i, h.err)
+			continue
+		}
+		if !reflect.DeepEqual(h.game.Tags, f.game.Tags) || h.game.Moves != nil || h.game.Result != f.game.Tags[TagResult] {
+			t.Errorf("game %d: headers %v, result %q, want %v", i, h.game.Tags, h.game.Result, f.game.Tags)
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	for name, data := range benchmarkInputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			games := 0
+			for i := 0; i < b.N; i++ {
+				r := NewReader(bytes.NewReader(data))
+				for _, err := range r.Games() {
+					if err != nil {
+						b.Fatal(err)
+					}
+					games++
+				}
+			}
+			b.ReportMetric(float64(games)/b.Elapsed().Seconds(), "games/s")
+		})
+	}
+}
+
+func BenchmarkGamesParallel(b *testing.B) {
+	for name, data := range benchmarkInputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			games := 0
+			for i := 0; i < b.N; i++ {
+				r := NewReader(bytes.NewReader(data))
+				for _, err := range r.GamesParallel(0) {
+					if err != nil {
+						b.Fatal(err)
+					}
+					games++
+				}
+			}
+			b.ReportMetric(float64(games)/b.Elapsed().Seconds(), "games/s")
+		})
+	}
+}
+
+func BenchmarkReaderHeadersOnly(b *testing.B) {
+	for name, data := range benchmarkInputs(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			games := 0
+			for i := 0; i < b.N; i++ {
+				r := NewReader(bytes.NewReader(data))
+				r.HeadersOnly = true
+				for _, err := range r.Games() {
+					if err != nil {
+						b.Fatal(err)
+					}
+					games++
+				}
+			}
+			b.ReportMetric(float64(games)/b.Elapsed().Seconds(), "games/s")
+		})
+	}
+}
@@ -2,13 +2,17 @@
 package pgn
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"rungine/internal/fen"
 )
 
 // Standard 7-tag roster.
@@ -22,6 +26,12 @@ const (
 	TagResult = "Result"
 )
 
+// Tags describing a game that starts from a non-standard position.
+const (
+	TagSetUp = "SetUp"
+	TagFEN   = "FEN"
+)
+
 // Game results.
 const (
 	ResultWhiteWins = "1-0"
@@ -36,6 +46,21 @@ var (
 	ErrUnmatchedParen  = errors.New("unmatched parenthesis")
 )
 
+// ParseError reports malformed PGN and where it was found.
+type ParseError struct {
+	Line int // 1-based line of the offending token
+	Col  int // 1-based column of the offending token
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Col, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // Game represents a parsed PGN game.
 type Game struct {
 	Tags   map[string]string // Tag pairs
@@ -45,28 +70,39 @@ type Game struct {
 
 // MoveNode represents a node in the move tree.
 type MoveNode struct {
-	Move       string      // SAN notation (e.g., "e4", "Nxf7+", "O-O-O")
-	Comment    string      // Text annotation after the move
-	NAGs       []int       // Numeric Annotation Glyphs ($1, $2, etc.)
-	Variations []*MoveNode // Alternative continuations
-	Next       *MoveNode   // Main line continuation
-	Parent     *MoveNode   // For navigation back
-	Ply        int         // Half-move number (0 = before first move)
+	Move        string          // SAN notation (e.g., "e4", "Nxf7+", "O-O-O")
+	Comment     string          // Text annotation after the move (before the first move on the root)
+	LineComment bool            // Comment was a ";" comment running to the end of the line
+	Clock       *time.Duration  // [%clk] remaining time after the move
+	Elapsed     *time.Duration  // [%emt] time spent on the move
+	Eval        *Eval           // [%eval] engine evaluation
+	Squares     []ColoredSquare // [%csl] highlighted squares
+	Arrows      []Arrow         // [%cal] arrows
+	NAGs        []int           // Numeric Annotation Glyphs ($1, $2, etc.)
+	Variations  []*MoveNode     // Alternative continuations
+	Next        *MoveNode       // Main line continuation
+	Parent      *MoveNode       // For navigation back
+	Ply         int             // Half-move number (0 = before first move)
+
+	// The comment text as read, commands included, and fullComment when it
+	// was read, to write unedited comments back as they were
+	rawComment, parsedComment string
 }
 
 // TokenType represents PGN token types.
 type TokenType int
 
 const (
-	TokenEOF TokenType = iota
-	TokenTag           // [Event "..."]
-	TokenMove          // e4, Nf3, O-O
-	TokenComment       // {text} or ;text
-	TokenNAG           // $1, !, ?, !!, ??, !?, ?!
-	TokenVariationStart // (
-	TokenVariationEnd   // )
-	TokenResult        // 1-0, 0-1, 1/2-1/2, *
-	TokenMoveNumber    // 1., 1...
+	TokenEOF            TokenType = iota
+	TokenTag                      // [Event "..."]
+	TokenMove                     // e4, Nf3, O-O
+	TokenComment                  // {text}
+	TokenNAG                      // $1, !, ?, !!, ??, !?, ?!
+	TokenVariationStart           // (
+	TokenVariationEnd             // )
+	TokenResult                   // 1-0, 0-1, 1/2-1/2, *
+	TokenMoveNumber               // 1., 1...
+	TokenLineComment              // ;text
 )
 
 // Token represents a PGN token.
@@ -77,23 +113,43 @@ type Token struct {
 	Col   int
 }
 
-// Tokenizer breaks PGN input into tokens.
+// Tokenizer breaks PGN input into tokens. It scans bytes, decoding UTF-8
+// only at non-ASCII characters, and shares the strings of repeated moves.
 type Tokenizer struct {
-	reader *bufio.Reader
+	src    io.Reader
+	buf    []byte // Input read so far; buf[pos:] is unread
+	pos    int
+	err    error // Read error, returned once buf is used up
 	line   int
 	col    int
 	peeked *Token
+
+	text     []byte            // Value of the token being scanned
+	interned map[string]string // Values of moves, move numbers and NAGs seen so far
 }
 
+// tokenizerBufSize is the size of the buffer input is read into.
+const tokenizerBufSize = 64 * 1024
+
+// maxInterned limits how many distinct token values a tokenizer keeps for reuse.
+const maxInterned = 4096
+
 // NewTokenizer creates a new PGN tokenizer.
 func NewTokenizer(r io.Reader) *Tokenizer {
 	return &Tokenizer{
-		reader: bufio.NewReader(r),
-		line:   1,
-		col:    1,
+		src:  r,
+		line: 1,
+		col:  1,
 	}
 }
 
+// reset makes the tokenizer scan data, without copying it, from the start.
+// Interned values are kept, so a tokenizer reused over many games allocates less.
+func (t *Tokenizer) reset(data []byte) {
+	t.src, t.buf, t.pos, t.err = nil, data, 0, io.EOF
+	t.line, t.col, t.peeked = 1, 1, nil
+}
+
 // Next returns the next token.
 func (t *Tokenizer) Next() (Token, error) {
 	if t.peeked != nil {
@@ -118,166 +174,137 @@ func (t *Tokenizer) Peek() (Token, error) {
 }
 
 func (t *Tokenizer) scanToken() (Token, error) {
-	t.skipWhitespace()
+	for {
+		t.skipWhitespace()
 
-	line, col := t.line, t.col
-	r, err := t.peek()
-	if err == io.EOF {
-		return Token{Type: TokenEOF, Line: line, Col: col}, nil
-	}
-	if err != nil {
-		return Token{}, err
-	}
+		line, col := t.line, t.col
+		c, ok := t.peekByte()
+		if !ok {
+			if t.err != io.EOF {
+				return Token{}, t.err
+			}
+			return Token{Type: TokenEOF, Line: line, Col: col}, nil
+		}
 
-	switch {
-	case r == '[':
-		return t.scanTag()
-	case r == '{':
-		return t.scanBraceComment()
-	case r == ';':
-		return t.scanLineComment()
-	case r == '(':
-		t.read()
-		return Token{Type: TokenVariationStart, Value: "(", Line: line, Col: col}, nil
-	case r == ')':
-		t.read()
-		return Token{Type: TokenVariationEnd, Value: ")", Line: line, Col: col}, nil
-	case r == '$':
-		return t.scanNAG()
-	case r == '!' || r == '?':
-		return t.scanSymbolicNAG()
-	case r == '*':
-		t.read()
-		return Token{Type: TokenResult, Value: "*", Line: line, Col: col}, nil
-	case r == '1' || r == '0':
-		return t.scanMoveNumberOrResult()
-	case unicode.IsLetter(r) || r == 'O':
-		return t.scanMove()
-	default:
-		t.read()
-		return t.scanToken() // Skip unknown characters
+		switch {
+		case c == '[':
+			return t.scanTag()
+		case c == '{':
+			return t.scanBraceComment()
+		case c == ';':
+			return t.scanLineComment()
+		case c == '(':
+			t.consume()
+			return Token{Type: TokenVariationStart, Value: "(", Line: line, Col: col}, nil
+		case c == ')':
+			t.consume()
+			return Token{Type: TokenVariationEnd, Value: ")", Line: line, Col: col}, nil
+		case c == '$':
+			return t.scanNAG()
+		case c == '!' || c == '?':
+			return t.scanSymbolicNAG()
+		case c == '*':
+			t.consume()
+			return Token{Type: TokenResult, Value: "*", Line: line, Col: col}, nil
+		case c == '1' || c == '0':
+			return t.scanMoveNumberOrResult()
+		case c >= utf8.RuneSelf:
+			if r, _ := t.peekRune(); unicode.IsLetter(r) {
+				return t.scanMove()
+			}
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			return t.scanMove()
+		}
+		t.consume() // Skip unknown characters
 	}
 }
 
 func (t *Tokenizer) scanTag() (Token, error) {
 	line, col := t.line, t.col
-	t.read() // consume '['
-
-	var sb strings.Builder
-	sb.WriteByte('[')
+	t.text = append(t.text[:0], t.consume()...) // '['
 
+	// A ] or an escaped quote inside the value does not end the tag
+	inQuote, escaped := false, false
 	for {
-		r, err := t.read()
-		if err != nil {
-			return Token{}, fmt.Errorf("%w: unclosed tag", ErrInvalidPGN)
+		c, ok := t.peekByte()
+		if !ok || (inQuote && c == '\n') {
+			return Token{}, &ParseError{Line: line, Col: col, Err: fmt.Errorf("%w: unclosed tag", ErrInvalidPGN)}
 		}
-		sb.WriteRune(r)
-		if r == ']' {
+		t.text = append(t.text, t.consume()...)
+		if escaped {
+			escaped = false
+		} else if inQuote && c == '\\' {
+			escaped = true
+		} else if c == '"' {
+			inQuote = !inQuote
+		} else if c == ']' && !inQuote {
 			break
 		}
 	}
 
-	return Token{Type: TokenTag, Value: sb.String(), Line: line, Col: col}, nil
+	return Token{Type: TokenTag, Value: string(t.text), Line: line, Col: col}, nil
 }
 
 func (t *Tokenizer) scanBraceComment() (Token, error) {
 	line, col := t.line, t.col
-	t.read() // consume '{'
+	t.consume() // '{'
 
-	var sb strings.Builder
+	t.text = t.text[:0]
 	depth := 1
-
-	for depth > 0 {
-		r, err := t.read()
-		if err != nil {
-			return Token{}, fmt.Errorf("%w: unclosed comment", ErrInvalidPGN)
+	for {
+		c, ok := t.peekByte()
+		if !ok {
+			return Token{}, &ParseError{Line: line, Col: col, Err: fmt.Errorf("%w: unclosed comment", ErrInvalidPGN)}
 		}
-		if r == '{' {
+		b := t.consume()
+		if c == '{' {
 			depth++
-			sb.WriteRune(r)
-		} else if r == '}' {
-			depth--
-			if depth > 0 {
-				sb.WriteRune(r)
+		} else if c == '}' {
+			if depth--; depth == 0 {
+				break
 			}
-		} else {
-			sb.WriteRune(r)
 		}
+		t.text = append(t.text, b...)
 	}
 
-	return Token{Type: TokenComment, Value: strings.TrimSpace(sb.String()), Line: line, Col: col}, nil
+	return Token{Type: TokenComment, Value: string(bytes.TrimSpace(t.text)), Line: line, Col: col}, nil
 }
 
 func (t *Tokenizer) scanLineComment() (Token, error) {
 	line, col := t.line, t.col
-	t.read() // consume ';'
+	t.consume() // ';'
 
-	var sb strings.Builder
+	t.text = t.text[:0]
 	for {
-		r, err := t.peek()
-		if err == io.EOF || r == '\n' {
+		c, ok := t.peekByte()
+		if !ok || c == '\n' {
 			break
 		}
-		t.read()
-		sb.WriteRune(r)
+		t.text = append(t.text, t.consume()...)
 	}
 
-	return Token{Type: TokenComment, Value: strings.TrimSpace(sb.String()), Line: line, Col: col}, nil
+	return Token{Type: TokenLineComment, Value: string(bytes.TrimSpace(t.text)), Line: line, Col: col}, nil
 }
 
 func (t *Tokenizer) scanNAG() (Token, error) {
 	line, col := t.line, t.col
-	t.read() // consume '$'
-
-	var sb strings.Builder
-	sb.WriteByte('$')
-
-	for {
-		r, err := t.peek()
-		if err != nil || !unicode.IsDigit(r) {
-			break
-		}
-		t.read()
-		sb.WriteRune(r)
-	}
-
-	return Token{Type: TokenNAG, Value: sb.String(), Line: line, Col: col}, nil
+	t.text = append(t.text[:0], t.consume()...) // '$'
+	t.scanWhile(unicode.IsDigit)
+	return Token{Type: TokenNAG, Value: t.intern(t.text), Line: line, Col: col}, nil
 }
 
 func (t *Tokenizer) scanSymbolicNAG() (Token, error) {
 	line, col := t.line, t.col
-
-	var sb strings.Builder
-	for {
-		r, err := t.peek()
-		if err != nil || (r != '!' && r != '?') {
-			break
-		}
-		t.read()
-		sb.WriteRune(r)
-	}
-
-	return Token{Type: TokenNAG, Value: sb.String(), Line: line, Col: col}, nil
+	t.text = t.text[:0]
+	t.scanWhile(func(r rune) bool { return r == '!' || r == '?' })
+	return Token{Type: TokenNAG, Value: t.intern(t.text), Line: line, Col: col}, nil
 }
 
 func (t *Tokenizer) scanMoveNumberOrResult() (Token, error) {
 	line, col := t.line, t.col
-
-	var sb strings.Builder
-	for {
-		r, err := t.peek()
-		if err != nil {
-			break
-		}
-		if unicode.IsDigit(r) || r == '.' || r == '/' || r == '-' {
-			t.read()
-			sb.WriteRune(r)
-		} else {
-			break
-		}
-	}
-
-	value := sb.String()
+	t.text = t.text[:0]
+	t.scanWhile(isMoveNumberChar)
+	value := t.intern(t.text)
 
 	// Check for result
 	if value == "1-0" || value == "0-1" || value == "1/2-1/2" {
@@ -290,56 +317,139 @@ func (t *Tokenizer) scanMoveNumberOrResult() (Token, error) {
 
 func (t *Tokenizer) scanMove() (Token, error) {
 	line, col := t.line, t.col
+	t.text = t.text[:0]
+	t.scanWhile(isMoveChar)
+	return Token{Type: TokenMove, Value: t.intern(t.text), Line: line, Col: col}, nil
+}
 
-	var sb strings.Builder
+// isMoveChar returns true for the characters of a move: letters, digits, -, +, #, = and x.
+func isMoveChar(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return true
+	case r < utf8.RuneSelf:
+		return r == '-' || r == '+' || r == '#' || r == '='
+	default:
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+}
+
+// isMoveNumberChar returns true for the characters of a move number or result.
+func isMoveNumberChar(r rune) bool {
+	if '0' <= r && r <= '9' || r == '.' || r == '/' || r == '-' {
+		return true
+	}
+	return r >= utf8.RuneSelf && unicode.IsDigit(r)
+}
+
+func (t *Tokenizer) skipWhitespace() {
 	for {
-		r, err := t.peek()
-		if err != nil {
-			break
+		c, ok := t.peekByte()
+		if !ok {
+			return
 		}
-		// Valid move characters: letters, digits, -, +, #, =, x
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '+' || r == '#' || r == '=' || r == 'x' {
-			t.read()
-			sb.WriteRune(r)
-		} else {
-			break
+		if c < utf8.RuneSelf {
+			if c != ' ' && c != '\n' && c != '\r' && c != '\t' && c != '\v' && c != '\f' {
+				return
+			}
+		} else if r, _ := t.peekRune(); !unicode.IsSpace(r) {
+			return
 		}
+		t.consume()
 	}
-
-	return Token{Type: TokenMove, Value: sb.String(), Line: line, Col: col}, nil
 }
 
-func (t *Tokenizer) skipWhitespace() {
+// scanWhile appends characters to text as long as match accepts them.
+func (t *Tokenizer) scanWhile(match func(rune) bool) {
 	for {
-		r, err := t.peek()
-		if err != nil || !unicode.IsSpace(r) {
+		c, ok := t.peekByte()
+		if !ok {
+			return
+		}
+		r := rune(c)
+		if c >= utf8.RuneSelf {
+			r, _ = t.peekRune()
+		}
+		if !match(r) {
 			return
 		}
-		t.read()
+		t.text = append(t.text, t.consume()...)
 	}
 }
 
-func (t *Tokenizer) peek() (rune, error) {
-	r, _, err := t.reader.ReadRune()
-	if err != nil {
-		return 0, err
+// intern returns b as a string, reusing the string of an earlier token with the same value.
+func (t *Tokenizer) intern(b []byte) string {
+	if s, ok := t.interned[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	if t.interned == nil {
+		t.interned = make(map[string]string)
 	}
-	t.reader.UnreadRune()
-	return r, nil
+	if len(t.interned) < maxInterned {
+		t.interned[s] = s
+	}
+	return s
 }
 
-func (t *Tokenizer) read() (rune, error) {
-	r, _, err := t.reader.ReadRune()
-	if err != nil {
-		return 0, err
+// peekByte returns the next byte without consuming it, or false at the end of the input.
+func (t *Tokenizer) peekByte() (byte, bool) {
+	if t.pos >= len(t.buf) && !t.fill() {
+		return 0, false
+	}
+	return t.buf[t.pos], true
+}
+
+// peekRune decodes the next character, which must be available, and returns it with its size in bytes.
+// Invalid UTF-8 counts as one character per byte.
+func (t *Tokenizer) peekRune() (rune, int) {
+	for !utf8.FullRune(t.buf[t.pos:]) && t.fill() {
 	}
-	if r == '\n' {
+	return utf8.DecodeRune(t.buf[t.pos:])
+}
+
+// consume moves past the next character, which must be available, and returns its bytes.
+// They are only valid until the next read.
+func (t *Tokenizer) consume() []byte {
+	start, n := t.pos, 1
+	switch c := t.buf[t.pos]; {
+	case c == '\n':
 		t.line++
-		t.col = 1
-	} else {
-		t.col++
+		t.col = 0
+	case c >= utf8.RuneSelf:
+		_, n = t.peekRune()
+		start = t.pos
+	}
+	t.col++
+	t.pos = start + n
+	return t.buf[start:t.pos]
+}
+
+// fill reads more input into the buffer, keeping the unread part, and returns false if there is none.
+func (t *Tokenizer) fill() bool {
+	if t.err != nil {
+		return false
+	}
+	if t.pos > 0 {
+		n := copy(t.buf, t.buf[t.pos:])
+		t.buf, t.pos = t.buf[:n], 0
+	}
+	if len(t.buf) == cap(t.buf) {
+		buf := make([]byte, len(t.buf), max(tokenizerBufSize, 2*cap(t.buf)))
+		copy(buf, t.buf)
+		t.buf = buf
+	}
+
+	for {
+		n, err := t.src.Read(t.buf[len(t.buf):cap(t.buf)])
+		t.buf = t.buf[:len(t.buf)+n]
+		if err != nil {
+			t.err = err
+		}
+		if n > 0 || err != nil {
+			return n > 0
+		}
 	}
-	return r, nil
 }
 
 // Parser parses PGN games from tokens.
@@ -354,24 +464,21 @@ func NewParser(r io.Reader) *Parser {
 	}
 }
 
+// reset makes the parser read a single game's text from data, which is not copied.
+func (p *Parser) reset(data []byte) {
+	p.tokenizer.reset(data)
+}
+
 // ParseGame parses a single game.
+// Malformed input is reported as a *ParseError.
 func (p *Parser) ParseGame() (*Game, error) {
 	game := &Game{
 		Tags: make(map[string]string),
 	}
 
 	// Parse tag section
-	for {
-		tok, err := p.tokenizer.Peek()
-		if err != nil {
-			return nil, err
-		}
-		if tok.Type != TokenTag {
-			break
-		}
-		p.tokenizer.Next()
-		name, value := parseTag(tok.Value)
-		game.Tags[name] = value
+	if err := p.parseTags(game.Tags); err != nil {
+		return nil, err
 	}
 
 	// Parse movetext
@@ -379,6 +486,7 @@ func (p *Parser) ParseGame() (*Game, error) {
 	current := root
 
 	var variationStack []*MoveNode
+	var variationStarts []Token
 
 	for {
 		tok, err := p.tokenizer.Next()
@@ -386,6 +494,11 @@ func (p *Parser) ParseGame() (*Game, error) {
 			return nil, err
 		}
 
+		if len(variationStarts) > 0 && (tok.Type == TokenEOF || tok.Type == TokenResult || tok.Type == TokenTag) {
+			open := variationStarts[len(variationStarts)-1]
+			return nil, &ParseError{Line: open.Line, Col: open.Col, Err: fmt.Errorf("%w: unclosed variation", ErrUnmatchedParen)}
+		}
+
 		switch tok.Type {
 		case TokenEOF:
 			game.Moves = root
@@ -412,10 +525,8 @@ func (p *Parser) ParseGame() (*Game, error) {
 			current.Next = node
 			current = node
 
-		case TokenComment:
-			if current != root {
-				current.Comment = tok.Value
-			}
+		case TokenComment, TokenLineComment:
+			current.addComment(tok.Value, tok.Type == TokenLineComment)
 
 		case TokenNAG:
 			if current != root {
@@ -426,28 +537,32 @@ func (p *Parser) ParseGame() (*Game, error) {
 			}
 
 		case TokenVariationStart:
+			if current.Parent == nil {
+				return nil, &ParseError{Line: tok.Line, Col: tok.Col, Err: fmt.Errorf("%w: variation before first move", ErrUnexpectedToken)}
+			}
 			// Save position to return to after variation
 			variationStack = append(variationStack, current)
+			variationStarts = append(variationStarts, tok)
 			// Branch from parent of current move (variation starts from same position)
-			if current.Parent != nil {
-				// Create a new branch point - next move in variation goes as child of parent
-				branchPoint := current.Parent
-				// Create placeholder for variation root
-				varRoot := &MoveNode{Parent: branchPoint, Ply: branchPoint.Ply}
-				branchPoint.Variations = append(branchPoint.Variations, varRoot)
-				current = varRoot
-			}
+			// Create a new branch point - next move in variation goes as child of parent
+			branchPoint := current.Parent
+			// Create placeholder for variation root
+			varRoot := &MoveNode{Parent: branchPoint, Ply: branchPoint.Ply}
+			branchPoint.Variations = append(branchPoint.Variations, varRoot)
+			current = varRoot
 
 		case TokenVariationEnd:
 			if len(variationStack) == 0 {
-				return nil, ErrUnmatchedParen
+				return nil, &ParseError{Line: tok.Line, Col: tok.Col, Err: ErrUnmatchedParen}
 			}
 			// Pop from stack - return to saved position
 			current = variationStack[len(variationStack)-1]
 			variationStack = variationStack[:len(variationStack)-1]
+			variationStarts = variationStarts[:len(variationStarts)-1]
 
 		case TokenTag:
-			// Tag in movetext starts new game
+			// Tag in movetext starts new game; leave it for the next ParseGame
+			p.tokenizer.peeked = &tok
 			game.Moves = root
 			if game.Result == "" {
 				game.Result = game.Tags[TagResult]
@@ -457,21 +572,72 @@ func (p *Parser) ParseGame() (*Game, error) {
 	}
 }
 
-// parseTag extracts name and value from a tag token like "[Event "World Championship"]".
-func parseTag(s string) (string, string) {
+// parseHeaders parses only the tag section of a game. The game has no move tree,
+// and its result is taken from the Result tag.
+func (p *Parser) parseHeaders() (*Game, error) {
+	game := &Game{
+		Tags: make(map[string]string),
+	}
+	if err := p.parseTags(game.Tags); err != nil {
+		return nil, err
+	}
+	game.Result = game.Tags[TagResult]
+	return game, nil
+}
+
+// parseTags adds tags to the map up to the first token that is not a tag.
+func (p *Parser) parseTags(tags map[string]string) error {
+	for {
+		tok, err := p.tokenizer.Peek()
+		if err != nil {
+			return err
+		}
+		if tok.Type != TokenTag {
+			return nil
+		}
+		p.tokenizer.Next()
+		name, value, ok := parseTag(tok.Value)
+		if !ok {
+			return &ParseError{Line: tok.Line, Col: tok.Col, Err: fmt.Errorf("%w: malformed tag %s", ErrInvalidPGN, tok.Value)}
+		}
+		tags[name] = value
+	}
+}
+
+// parseTag extracts name and value from a tag token like "[Event "World Championship"]",
+// undoing the \" and \\ escapes in the value.
+// It returns false if the token is not a name followed by a quoted value.
+func parseTag(s string) (string, string, bool) {
 	// Remove brackets
 	s = strings.TrimPrefix(s, "[")
 	s = strings.TrimSuffix(s, "]")
 
 	// Split at first space
-	parts := strings.SplitN(s, " ", 2)
-	if len(parts) < 2 {
-		return parts[0], ""
+	name, quoted, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok || name == "" {
+		return name, "", false
 	}
 
-	name := parts[0]
-	value := strings.Trim(parts[1], "\"")
-	return name, value
+	quoted = strings.TrimSpace(quoted)
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return name, "", false
+	}
+
+	value := quoted[1 : len(quoted)-1]
+	if !strings.Contains(value, `\`) {
+		return name, value, true
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			i++
+			c = value[i]
+		}
+		sb.WriteByte(c)
+	}
+	return name, sb.String(), true
 }
 
 // parseNAG converts NAG string to integer.
@@ -502,106 +668,6 @@ func parseNAG(s string) int {
 	return 0
 }
 
-// String returns the PGN representation of a game.
-func (g *Game) String() string {
-	var sb strings.Builder
-
-	// Write tags
-	roster := []string{TagEvent, TagSite, TagDate, TagRound, TagWhite, TagBlack, TagResult}
-	for _, tag := range roster {
-		value, ok := g.Tags[tag]
-		if !ok {
-			value = "?"
-		}
-		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag, value)
-	}
-
-	// Write other tags
-	for name, value := range g.Tags {
-		found := false
-		for _, tag := range roster {
-			if name == tag {
-				found = true
-				break
-			}
-		}
-		if !found {
-			fmt.Fprintf(&sb, "[%s \"%s\"]\n", name, value)
-		}
-	}
-
-	sb.WriteByte('\n')
-
-	// Write moves
-	if g.Moves != nil && g.Moves.Next != nil {
-		g.writeMoves(&sb, g.Moves.Next, true)
-	}
-
-	// Write result
-	if g.Result != "" {
-		sb.WriteString(g.Result)
-	}
-
-	return sb.String()
-}
-
-func (g *Game) writeMoves(sb *strings.Builder, node *MoveNode, needNumber bool) {
-	for node != nil {
-		// Write move number if needed
-		if needNumber || node.Ply%2 == 1 {
-			moveNum := (node.Ply + 1) / 2
-			if node.Ply%2 == 1 {
-				fmt.Fprintf(sb, "%d. ", moveNum)
-			} else {
-				fmt.Fprintf(sb, "%d... ", moveNum)
-			}
-			needNumber = false
-		}
-
-		// Write move
-		sb.WriteString(node.Move)
-
-		// Write NAGs
-		for _, nag := range node.NAGs {
-			sb.WriteString(nagToSymbol(nag))
-		}
-
-		// Write comment
-		if node.Comment != "" {
-			fmt.Fprintf(sb, " {%s}", node.Comment)
-		}
-
-		// Write variations
-		for _, variation := range node.Variations {
-			sb.WriteString(" (")
-			g.writeMoves(sb, variation, true)
-			sb.WriteByte(')')
-		}
-
-		sb.WriteByte(' ')
-		node = node.Next
-	}
-}
-
-func nagToSymbol(nag int) string {
-	switch nag {
-	case 1:
-		return "!"
-	case 2:
-		return "?"
-	case 3:
-		return "!!"
-	case 4:
-		return "??"
-	case 5:
-		return "!?"
-	case 6:
-		return "?!"
-	default:
-		return fmt.Sprintf("$%d", nag)
-	}
-}
-
 // MainLine returns the moves of the main line as a slice.
 func (g *Game) MainLine() []string {
 	var moves []string
@@ -616,6 +682,29 @@ func (g *Game) MainLine() []string {
 	return moves
 }
 
+// StartPosition returns the position the game starts from.
+// This is the FEN tag's position if present, otherwise the standard starting position.
+func (g *Game) StartPosition() (*fen.Position, error) {
+	if fenStr, ok := g.Tags[TagFEN]; ok && g.Tags[TagSetUp] != "0" {
+		pos, err := fen.Parse(fenStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: FEN tag: %w", ErrInvalidPGN, err)
+		}
+		return pos, nil
+	}
+	return fen.StartingPosition(), nil
+}
+
+// MainLineUCI returns the moves of the main line in UCI notation,
+// ready to be sent to an engine along with the game's starting FEN.
+func (g *Game) MainLineUCI() ([]string, error) {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return nil, err
+	}
+	return fen.SANToUCI(pos, g.MainLine())
+}
+
 // NewGame creates a new empty game.
 func NewGame() *Game {
 	return &Game{
@@ -378,3 +378,56 @@ func TestAddMove(t *testing.T) {
 		t.Errorf("MainLine() len = %d, want 2", len(mainLine))
 	}
 }
+
+func TestMainLineUCI(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "standard start",
+			input: `1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Bxc6 dxc6 5. O-O f6 6. d4 exd4 7. Nxd4 c5 8. Nb3 Qxd1 9. Rxd1 *`,
+			want:  []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6", "b5c6", "d7c6", "e1g1", "f7f6", "d2d4", "e5d4", "f3d4", "c6c5", "d4b3", "d8d1", "f1d1"},
+		},
+		{
+			name: "illegal move from FEN tag",
+			input: `[SetUp "1"]
+[FEN "4k3/4P3/4K3/8/8/8/8/8 b - - 0 1"]
+
+1... Kf7 2. e8=Q+ *`,
+			wantErr: true,
+		},
+		{
+			name: "FEN tag with promotion",
+			input: `[SetUp "1"]
+[FEN "8/P7/8/8/8/8/k7/4K3 w - - 0 1"]
+
+1. a8=N Kb3 *`,
+			want: []string{"a7a8n", "a2b3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := NewParser(strings.NewReader(tc.input)).ParseGame()
+			if err != nil {
+				t.Fatalf("ParseGame() error: %v", err)
+			}
+			got, err := game.MainLineUCI()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("MainLineUCI() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MainLineUCI() error: %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("MainLineUCI() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package pgn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+)
+
+// Reader reads the games of a multi-game PGN file one at a time.
+//
+// The input is split into games before parsing: a game ends where a tag line
+// follows movetext, or where a second [Event tag appears. A malformed game is
+// therefore reported on its own and reading carries on with the next game.
+type Reader struct {
+	// HeadersOnly makes Read parse only the tag pairs of each game, which is much
+	// faster when the moves are not needed, e.g., for indexing. The games have no
+	// move tree, their Result comes from the Result tag, and errors in the movetext go unnoticed.
+	HeadersOnly bool
+
+	br     *bufio.Reader
+	parser *Parser
+
+	offset int64 // Bytes consumed from br
+	line   int   // Number of the next line in br
+
+	long          []byte // Line too long for br's buffer
+	pending       []byte // First line of the next game, already consumed
+	pendingOffset int64
+	pendingLine   int
+
+	gameOffset int64
+	gameEnd    int64 // Offset just past the game last returned, before the next one starts
+	gameLine   int
+	err        error
+}
+
+// NewReader creates a reader over a multi-game PGN stream.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		br:     bufio.NewReaderSize(r, 64*1024),
+		parser: NewParser(nil),
+		line:   1,
+	}
+}
+
+// Read returns the next game. It returns io.EOF when there are no more games.
+// A malformed game is returned as a *ParseError with lines counted from the
+// start of the input; Read may be called again to continue with the next game.
+// Any other error comes from the underlying reader and ends the stream.
+func (r *Reader) Read() (*Game, error) {
+	chunk, err := r.nextChunk()
+	if err != nil {
+		return nil, err
+	}
+	return parseChunk(r.parser, chunk, r.gameLine, r.HeadersOnly)
+}
+
+// parseChunk parses the text of one game, which starts at line of the input.
+// Errors are returned as a *ParseError with lines counted from the start of the input.
+func parseChunk(p *Parser, chunk []byte, line int, headersOnly bool) (*Game, error) {
+	p.reset(chunk)
+	var game *Game
+	var err error
+	if headersOnly {
+		game, err = p.parseHeaders()
+	} else {
+		game, err = p.ParseGame()
+	}
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			return nil, &ParseError{Line: line + perr.Line - 1, Col: perr.Col, Err: perr.Err}
+		}
+		return nil, &ParseError{Line: line, Col: 1, Err: err}
+	}
+	return game, nil
+}
+
+// Offset returns the byte offset of the game last returned by Read.
+func (r *Reader) Offset() int64 {
+	return r.gameOffset
+}
+
+// Line returns the line number of the game last returned by Read.
+func (r *Reader) Line() int {
+	return r.gameLine
+}
+
+// Games returns an iterator over the remaining games. Malformed games are
+// yielded with a *ParseError and iteration continues; iteration stops at the
+// end of the input or after an I/O error, which is yielded last.
+func (r *Reader) Games() iter.Seq2[*Game, error] {
+	return func(yield func(*Game, error) bool) {
+		for {
+			game, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			var perr *ParseError
+			if !yield(game, err) || (err != nil && !errors.As(err, &perr)) {
+				return
+			}
+		}
+	}
+}
+
+// nextChunk returns the text of the next game and records where it starts.
+// Leading blank lines and % escape lines are skipped.
+func (r *Reader) nextChunk() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var chunk []byte
+	started := false
+	sawMovetext, sawEvent := false, false
+	inComment := false
+
+	add := func(line []byte, offset int64, lineNum int) {
+		if !started {
+			if len(bytes.TrimSpace(line)) == 0 {
+				return
+			}
+			started = true
+			r.gameOffset, r.gameLine = offset, lineNum
+		}
+		chunk = append(chunk, line...)
+	}
+
+	if r.pending != nil {
+		add(r.pending, r.pendingOffset, r.pendingLine)
+		sawEvent = isEventTag(r.pending)
+		r.pending = nil
+	}
+
+	for {
+		line, err := r.readLine()
+		offset, lineNum := r.offset, r.line
+		r.offset += int64(len(line))
+		if len(line) > 0 {
+			r.line++
+		}
+
+		if len(line) > 0 && (inComment || line[0] != '%') {
+			tag, event := !inComment && isTagLine(line), isEventTag(line)
+			if (tag && sawMovetext) || (event && (sawMovetext || sawEvent)) {
+				// This line belongs to the next game. An [Event line ends the game
+				// even inside an unterminated comment, so a broken game cannot swallow the rest.
+				r.pending, r.pendingOffset, r.pendingLine = bytes.Clone(line), offset, lineNum
+				r.gameEnd = offset
+				return chunk, nil
+			}
+			if tag {
+				sawEvent = sawEvent || event
+			} else {
+				var hasText bool
+				inComment, hasText = scanMovetextLine(line, inComment)
+				sawMovetext = sawMovetext || hasText
+			}
+			add(line, offset, lineNum)
+		}
+
+		if err != nil {
+			// Hand out what was read; the error is returned by the next call
+			r.err = err
+			if started {
+				r.gameEnd = r.offset
+				return chunk, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// readLine returns the next line with its newline. It is only valid until the next call.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.br.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+
+	// Longer than the buffer
+	r.long = append(r.long[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = r.br.ReadSlice('\n')
+		r.long = append(r.long, line...)
+	}
+	return r.long, err
+}
+
+// isTagLine returns true if a line starts with a tag pair.
+func isTagLine(line []byte) bool {
+	line = bytes.TrimLeft(line, " \t")
+	return len(line) > 0 && line[0] == '['
+}
+
+// isEventTag returns true if a line starts with an Event tag.
+func isEventTag(line []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(line, " \t"), []byte("[Event "))
+}
+
+// scanMovetextLine tracks brace comments over a movetext line. It returns whether
+// a comment is still open at the end of the line and whether the line had any text.
+func scanMovetextLine(line []byte, inComment bool) (bool, bool) {
+	hasText := false
+	for _, c := range line {
+		switch {
+		case inComment:
+			hasText = true
+			if c == '}' {
+				inComment = false
+			}
+		case c == '{':
+			hasText, inComment = true, true
+		case c == ';':
+			return false, true
+		case c != ' ' && c != '\t' && c != '\r' && c != '\n':
+			hasText = true
+		}
+	}
+	return inComment, hasText
+}
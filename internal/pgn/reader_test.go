@@ -0,0 +1,144 @@
+package pgn
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReaderMultipleGames(t *testing.T) {
+	input := `[Event "One"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 {threat
+[not a tag]} Nc6 1-0
+
+% escaped line
+[Event "Two"]
+[Result "*"]
+
+1. d4 *
+[Event "Three"]
+[Result "0-1"]
+1. f3 e5 2. g4 Qh4# 0-1
+`
+
+	r := NewReader(strings.NewReader(input))
+	want := []struct {
+		event  string
+		moves  int
+		line   int
+		offset int64
+	}{
+		{"One", 4, 1, 0},
+		{"Two", 1, 8, int64(strings.Index(input, `[Event "Two"]`))},
+		{"Three", 4, 12, int64(strings.Index(input, `[Event "Three"]`))},
+	}
+
+	for _, w := range want {
+		game, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+		if game.Tags[TagEvent] != w.event {
+			t.Errorf("Event = %q, want %q", game.Tags[TagEvent], w.event)
+		}
+		if got := len(game.MainLine()); got != w.moves {
+			t.Errorf("%s: %d moves, want %d", w.event, got, w.moves)
+		}
+		if r.Line() != w.line || r.Offset() != w.offset {
+			t.Errorf("%s: at line %d offset %d, want line %d offset %d", w.event, r.Line(), r.Offset(), w.line, w.offset)
+		}
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read() at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReaderRecovery(t *testing.T) {
+	input := `[Event "Good"]
+[Result "*"]
+
+1. e4 *
+
+[Event "Unmatched"]
+[Result "*"]
+
+1. e4 e5 ) 2. Nf3 *
+
+[Event "Unclosed variation"]
+
+1. e4 (1. d4 d5
+
+[Event "Unclosed comment"]
+
+1. e4 {never closed
+
+[Event "Bad tag"]
+[White Carlsen]
+
+1. e4 *
+
+[Event "Last"]
+[Result "1-0"]
+
+1. e4 1-0`
+
+	tests := []struct {
+		event string
+		err   error
+		line  int
+		col   int
+	}{
+		{event: "Good"},
+		{err: ErrUnmatchedParen, line: 9, col: 10},
+		{err: ErrUnmatchedParen, line: 13, col: 7},
+		{err: ErrInvalidPGN, line: 17, col: 7},
+		{err: ErrInvalidPGN, line: 20, col: 1},
+		{event: "Last"},
+	}
+
+	r := NewReader(strings.NewReader(input))
+	i := 0
+	for game, err := range r.Games() {
+		if i >= len(tests) {
+			t.Fatalf("unexpected extra game %v, %v", game, err)
+		}
+		tc := tests[i]
+		i++
+
+		if tc.err == nil {
+			if err != nil {
+				t.Errorf("game %d: unexpected error %v", i, err)
+			} else if game.Tags[TagEvent] != tc.event {
+				t.Errorf("game %d: Event = %q, want %q", i, game.Tags[TagEvent], tc.event)
+			}
+			continue
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("game %d: error = %v, want *ParseError", i, err)
+			continue
+		}
+		if !errors.Is(err, tc.err) {
+			t.Errorf("game %d: error = %v, want %v", i, err, tc.err)
+		}
+		if perr.Line != tc.line || perr.Col != tc.col {
+			t.Errorf("game %d: error at %d:%d, want %d:%d", i, perr.Line, perr.Col, tc.line, tc.col)
+		}
+	}
+	if i != len(tests) {
+		t.Errorf("got %d games, want %d", i, len(tests))
+	}
+}
+
+func TestReaderEmpty(t *testing.T) {
+	for _, input := range []string{"", "\n\n  \n"} {
+		if _, err := NewReader(strings.NewReader(input)).Read(); err != io.EOF {
+			t.Errorf("Read(%q) = %v, want io.EOF", input, err)
+		}
+	}
+}
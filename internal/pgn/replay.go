@@ -0,0 +1,84 @@
+package pgn
+
+import (
+	"fmt"
+
+	"rungine/internal/fen"
+)
+
+// IllegalMoveError reports a move in a game that cannot be played in its position.
+type IllegalMoveError struct {
+	Move string
+	Ply  int
+	Path []int // Path of the move, as returned by MoveNode.Path
+	Err  error
+}
+
+func (e *IllegalMoveError) Error() string {
+	return fmt.Sprintf("move %s at ply %d (path %v): %v", e.Move, e.Ply, e.Path, e.Err)
+}
+
+func (e *IllegalMoveError) Unwrap() error {
+	return e.Err
+}
+
+// Replay plays the main line and every variation from the game's start position
+// (the FEN tag's, if any) and returns the position after each node's move.
+// The root and the variation placeholders map to the position before the next move.
+// If a move cannot be played, Replay returns an *IllegalMoveError for the first one,
+// looking at the main line before its variations.
+func (g *Game) Replay() (map[*MoveNode]*fen.Position, error) {
+	positions := make(map[*MoveNode]*fen.Position)
+	err := g.replay(func(node *MoveNode, pos *fen.Position) {
+		p := *pos
+		positions[node] = &p
+	})
+	if err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// Validate checks that every move in the game, including variations, is legal.
+// It returns the same errors as Replay without keeping the positions.
+func (g *Game) Validate() error {
+	return g.replay(func(*MoveNode, *fen.Position) {})
+}
+
+func (g *Game) replay(visit func(*MoveNode, *fen.Position)) error {
+	pos, err := g.StartPosition()
+	if err != nil {
+		return err
+	}
+	if g.Moves == nil {
+		return nil
+	}
+	return replayFrom(g.Moves, pos, visit)
+}
+
+// replayFrom visits n, whose move has been played on pos, and then the lines following it:
+// Next, then each variation, from the placeholder on. A placeholder has the position n has,
+// so its own nested variations are replayed from there too. pos is restored before returning.
+func replayFrom(n *MoveNode, pos *fen.Position, visit func(*MoveNode, *fen.Position)) error {
+	visit(n, pos)
+
+	if next := n.Next; next != nil {
+		m, err := pos.ParseSAN(next.Move)
+		if err != nil {
+			return &IllegalMoveError{Move: next.Move, Ply: next.Ply, Path: next.Path(), Err: err}
+		}
+		u := pos.MakeMove(m)
+		err = replayFrom(next, pos, visit)
+		pos.UnmakeMove(m, u)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, v := range n.Variations {
+		if err := replayFrom(v, pos, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
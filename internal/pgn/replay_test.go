@@ -0,0 +1,141 @@
+package pgn
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"rungine/internal/fen"
+)
+
+func TestReplay(t *testing.T) {
+	input := `1. e4 e5 (1... c5 2. Nf3 (2. c3 d5) 2... d6) 2. Nf3 *`
+	game, err := NewParser(strings.NewReader(input)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+
+	positions, err := game.Replay()
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+
+	tests := []struct {
+		path []int
+		fen  string
+	}{
+		{[]int{}, fen.StartingFEN},
+		{[]int{0, 0, 0}, "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"},
+		{[]int{0, 1, 1, 0}, "rnbqkbnr/pp2pppp/8/2pp4/4P3/2P5/PP1P1PPP/RNBQKBNR w KQkq d6 0 3"},
+		{[]int{0, 1, 0, 0}, "rnbqkbnr/pp2pppp/3p4/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 3"},
+	}
+	for _, tc := range tests {
+		node, err := game.Node(tc.path)
+		if err != nil {
+			t.Fatalf("Node(%v) error: %v", tc.path, err)
+		}
+		pos, ok := positions[node]
+		if !ok {
+			t.Errorf("no position for %v", tc.path)
+			continue
+		}
+		if got := pos.String(); got != tc.fen {
+			t.Errorf("position at %v = %q, want %q", tc.path, got, tc.fen)
+		}
+	}
+
+	// The variation placeholder holds the position before its first move
+	e4, _ := game.Node([]int{0})
+	if got := positions[e4.Variations[0]].String(); got != positions[e4].String() {
+		t.Errorf("placeholder position = %q, want %q", got, positions[e4].String())
+	}
+}
+
+func TestReplayNestedVariation(t *testing.T) {
+	game, err := NewParser(strings.NewReader(`1. e4 (1. d4 (1. c4 c5) d5) e5 *`)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+	positions, err := game.Replay()
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+
+	c5, err := game.Node([]int{2, 0})
+	if err != nil {
+		t.Fatalf("Node() error: %v", err)
+	}
+	want := "rnbqkbnr/pp1ppppp/8/2p5/2P5/8/PP1PPPPP/RNBQKBNR w KQkq c6 0 2"
+	if pos, ok := positions[c5]; !ok {
+		t.Error("no position for the nested variation")
+	} else if got := pos.String(); got != want {
+		t.Errorf("position after c5 = %q, want %q", got, want)
+	}
+
+	// Both placeholders hold the start position
+	for _, ph := range []*MoveNode{game.Moves.Variations[0], game.Moves.Variations[0].Variations[0]} {
+		if pos, ok := positions[ph]; !ok || pos.String() != fen.StartingFEN {
+			t.Errorf("placeholder position = %v, want the start position", pos)
+		}
+	}
+}
+
+func TestReplayIllegalMove(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		move  string
+		ply   int
+		path  []int
+	}{
+		{
+			name:  "main line",
+			input: `1. e4 e5 2. Ke3 Nc6 *`,
+			move:  "Ke3", ply: 3, path: []int{0, 0, 0},
+		},
+		{
+			name:  "variation",
+			input: `1. e4 e5 (1... c5 2. Nf3 (2. Nf4 d5) 2... d6) 2. Nf3 *`,
+			move:  "Nf4", ply: 3, path: []int{0, 1, 1},
+		},
+		{
+			name:  "nested variation",
+			input: `1. e4 (1. d4 (1. Ke2)) e5 *`,
+			move:  "Ke2", ply: 1, path: []int{2},
+		},
+		{
+			name: "from FEN tag",
+			input: `[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 1"]
+
+1... Kd7 2. e5 *`,
+			move: "e5", ply: 2, path: []int{0, 0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			game, err := NewParser(strings.NewReader(tc.input)).ParseGame()
+			if err != nil {
+				t.Fatalf("ParseGame() error: %v", err)
+			}
+
+			_, err = game.Replay()
+			var illegal *IllegalMoveError
+			if !errors.As(err, &illegal) {
+				t.Fatalf("Replay() error = %v, want *IllegalMoveError", err)
+			}
+			if illegal.Move != tc.move || illegal.Ply != tc.ply || !slices.Equal(illegal.Path, tc.path) {
+				t.Errorf("illegal move %s at ply %d path %v, want %s at ply %d path %v",
+					illegal.Move, illegal.Ply, illegal.Path, tc.move, tc.ply, tc.path)
+			}
+			if !errors.Is(err, fen.ErrIllegalMove) && !errors.Is(err, fen.ErrInvalidMove) {
+				t.Errorf("Replay() error = %v, want it to wrap the fen error", err)
+			}
+			if err := game.Validate(); err == nil {
+				t.Error("Validate() = nil, want error")
+			}
+		})
+	}
+}
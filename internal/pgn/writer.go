@@ -0,0 +1,279 @@
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"rungine/internal/fen"
+)
+
+// DefaultLineWidth is the maximum length of a movetext line in PGN export format.
+const DefaultLineWidth = 80
+
+// sevenTagRoster lists the tags every exported game starts with, in order.
+var sevenTagRoster = []string{TagEvent, TagSite, TagDate, TagRound, TagWhite, TagBlack, TagResult}
+
+var tagEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Writer writes games in PGN export format, separated by blank lines.
+type Writer struct {
+	w *bufio.Writer
+
+	// NumericNAGs writes every NAG as $n instead of using !, ?, !!, ??, !? and ?!.
+	NumericNAGs bool
+	// LineWidth is the maximum movetext line length; zero means DefaultLineWidth.
+	LineWidth int
+}
+
+// NewWriter creates a new PGN writer. Call Flush when done.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes a game followed by a blank line.
+func (w *Writer) Write(g *Game) error {
+	e := exporter{numericNAGs: w.NumericNAGs, width: w.LineWidth}
+	if _, err := w.w.WriteString(e.export(g)); err != nil {
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// String returns the game in PGN export format: the Seven Tag Roster, the
+// other tags sorted by name, a blank line and the movetext wrapped at
+// DefaultLineWidth columns, ending with the result and a newline.
+func (g *Game) String() string {
+	e := exporter{}
+	return e.export(g)
+}
+
+// exporter lays out a game in PGN export format.
+type exporter struct {
+	numericNAGs bool
+	width       int
+
+	// Move numbering of the first move, which depends on the start position
+	firstMove  int
+	blackFirst bool
+
+	words  []string
+	breaks map[int]bool // Word indexes that must end their line
+	prefix string       // Opening parenthesis waiting for the next word
+}
+
+func (e *exporter) export(g *Game) string {
+	var sb strings.Builder
+	g.writeTags(&sb)
+	sb.WriteByte('\n')
+
+	if e.width <= 0 {
+		e.width = DefaultLineWidth
+	}
+	e.firstMove, e.blackFirst = 1, false
+	if pos, err := g.StartPosition(); err == nil {
+		e.firstMove, e.blackFirst = pos.FullmoveNum, pos.SideToMove == fen.Black
+	}
+	e.words, e.breaks, e.prefix = nil, make(map[int]bool), ""
+
+	if g.Moves != nil {
+		e.writeLine(g.Moves)
+	}
+	e.word(g.termination())
+
+	line := ""
+	for i, w := range e.words {
+		switch {
+		case line == "":
+			line = lineStart(w)
+		case len(line)+1+len(w) > e.width:
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+			line = lineStart(w)
+		default:
+			line += " " + w
+		}
+		if e.breaks[i] {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+			line = ""
+		}
+	}
+	if line != "" {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// lineStart returns a word to start a line of movetext with. A "%" in the first
+// column makes the line an escape, which readers skip, so a word of comment text
+// starting with one is indented.
+func lineStart(w string) string {
+	if strings.HasPrefix(w, "%") {
+		return " " + w
+	}
+	return w
+}
+
+// writeTags writes the Seven Tag Roster and then the other tags sorted by name.
+func (g *Game) writeTags(sb *strings.Builder) {
+	for _, tag := range sevenTagRoster {
+		value, ok := g.Tags[tag]
+		if !ok {
+			value = defaultTagValue(tag, g)
+		}
+		fmt.Fprintf(sb, "[%s \"%s\"]\n", tag, tagEscaper.Replace(value))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(g.Tags)) {
+		if slices.Contains(sevenTagRoster, name) {
+			continue
+		}
+		fmt.Fprintf(sb, "[%s \"%s\"]\n", name, tagEscaper.Replace(g.Tags[name]))
+	}
+}
+
+// defaultTagValue returns the value written for a missing Seven Tag Roster tag.
+func defaultTagValue(tag string, g *Game) string {
+	switch tag {
+	case TagDate:
+		return "????.??.??"
+	case TagResult:
+		return g.termination()
+	default:
+		return "?"
+	}
+}
+
+// termination returns the game termination marker ending the movetext.
+func (g *Game) termination() string {
+	if g.Result != "" {
+		return g.Result
+	}
+	if result := g.Tags[TagResult]; result != "" {
+		return result
+	}
+	return ResultOngoing
+}
+
+// writeLine writes the line continuing from start, which is the root or a variation's placeholder node.
+func (e *exporter) writeLine(start *MoveNode) {
+	// Comment before the first move of the game or variation
+	e.comment(start)
+
+	needNumber := true
+	for node := start; node.Next != nil; node = node.Next {
+		move := node.Next
+		e.move(move, needNumber)
+		needNumber = e.comment(move)
+
+		for _, variation := range node.Variations {
+			e.prefix += "("
+			e.writeLine(variation)
+			e.closeParen()
+			needNumber = true
+		}
+	}
+}
+
+// move writes a move with its number, if due, and its NAGs.
+func (e *exporter) move(node *MoveNode, needNumber bool) {
+	number, black := e.moveNumber(node.Ply)
+	if !black {
+		e.word(strconv.Itoa(number) + ".")
+	} else if needNumber {
+		e.word(strconv.Itoa(number) + "...")
+	}
+	e.word(node.Move)
+
+	for _, nag := range node.NAGs {
+		if e.numericNAGs || nag > 6 {
+			e.word("$" + strconv.Itoa(nag))
+		} else {
+			// Symbolic NAGs are attached to the move
+			e.words[len(e.words)-1] += nagToSymbol(nag)
+		}
+	}
+}
+
+// moveNumber returns the move number of a ply and whether it is a Black move.
+func (e *exporter) moveNumber(ply int) (int, bool) {
+	half := ply - 1
+	if e.blackFirst {
+		half++
+	}
+	return e.firstMove + half/2, half%2 == 1
+}
+
+// comment writes a node's comment, including its commands, and returns true if it wrote one.
+// A ";" comment is kept as such unless it spans several lines.
+func (e *exporter) comment(node *MoveNode) bool {
+	text := node.exportComment()
+	if text == "" {
+		return false
+	}
+
+	if node.LineComment && !strings.ContainsAny(text, "\r\n") {
+		e.word("; " + text)
+		e.breaks[len(e.words)-1] = true
+		return true
+	}
+
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		e.word("{}")
+		return true
+	}
+	words[0] = "{" + words[0]
+	words[len(words)-1] += "}"
+	for _, w := range words {
+		e.word(w)
+	}
+	return true
+}
+
+// word appends a word of movetext, after any pending "(".
+func (e *exporter) word(w string) {
+	e.words = append(e.words, e.prefix+w)
+	e.prefix = ""
+}
+
+// closeParen attaches ")" to the last word, unless that is a ";" comment.
+func (e *exporter) closeParen() {
+	last := len(e.words) - 1
+	if e.prefix != "" || last < 0 || e.breaks[last] {
+		// Empty variation or the line is taken by a comment
+		e.word(")")
+		return
+	}
+	e.words[last] += ")"
+}
+
+func nagToSymbol(nag int) string {
+	switch nag {
+	case 1:
+		return "!"
+	case 2:
+		return "?"
+	case 3:
+		return "!!"
+	case 4:
+		return "??"
+	case 5:
+		return "!?"
+	case 6:
+		return "?!"
+	default:
+		return fmt.Sprintf("$%d", nag)
+	}
+}
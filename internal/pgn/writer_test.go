@@ -0,0 +1,152 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+// exportedGames is a file in PGN export format; reading and writing it must not change a byte.
+const exportedGames = `[Event "Quote \"test\" \\ ok"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Carlsen, Magnus"]
+[Black "?"]
+[Result "1-0"]
+[Annotator "me"]
+[ECO "B90"]
+
+{Game comment before the first move} 1. e4 c5 {Sicilian} 2. Nf3 (2. c3 d5 (2...
+Nf6 3. e5) 3. exd5) ({Closed} 2. Nc3) 2... d6! 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6
+$10 6. Be3 e5 7. Nb3 Be6 8. f3 ; English attack
+8... Be7 9. Qd2 O-O 10. O-O-O Nbd7 11. g4 b5 12. g5 b4 13. Ne2 Ne8 14. f4 a5 15.
+f5 a4 16. Nbd4 exd4 17. Nxd4 b3 {A very long comment that will need to be
+wrapped across more than one line of output text} 18. Kb1 1-0
+
+[Event "FEN"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 23"]
+[SetUp "1"]
+
+23... Kd7 24. e4 Kd6 (24... Ke6 25. Ke2) *
+
+`
+
+func TestWriterRoundTrip(t *testing.T) {
+	var sb strings.Builder
+	w := NewWriter(&sb)
+	for game, err := range NewReader(strings.NewReader(exportedGames)).Games() {
+		if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+		if err := w.Write(game); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	if sb.String() != exportedGames {
+		t.Errorf("round trip changed the file:\n%s", sb.String())
+	}
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if len(line) > DefaultLineWidth {
+			t.Errorf("line longer than %d columns: %q", DefaultLineWidth, line)
+		}
+	}
+}
+
+func TestGameStringTags(t *testing.T) {
+	input := `[Opening "Sicilian"]
+[White "Anand"]
+[ECO "B90"]
+[Event "Path C:\\games \"2024\""]
+
+1. e4 c5 *`
+
+	game, err := NewParser(strings.NewReader(input)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+	if want := `Path C:\games "2024"`; game.Tags[TagEvent] != want {
+		t.Errorf("Event = %q, want %q", game.Tags[TagEvent], want)
+	}
+
+	want := `[Event "Path C:\\games \"2024\""]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Anand"]
+[Black "?"]
+[Result "*"]
+[ECO "B90"]
+[Opening "Sicilian"]
+
+1. e4 c5 *
+`
+	if got := game.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriterNumericNAGs(t *testing.T) {
+	game, err := NewParser(strings.NewReader(`1. e4! e5?! 2. Nf3 $14 *`)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+
+	tests := []struct {
+		numeric bool
+		want    string
+	}{
+		{false, "1. e4! e5?! 2. Nf3 $14 *"},
+		{true, "1. e4 $1 e5 $6 2. Nf3 $14 *"},
+	}
+
+	for _, tc := range tests {
+		var sb strings.Builder
+		w := NewWriter(&sb)
+		w.NumericNAGs = tc.numeric
+		if err := w.Write(game); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+		w.Flush()
+		if !strings.Contains(sb.String(), "\n"+tc.want+"\n") {
+			t.Errorf("NumericNAGs=%v: movetext not %q in\n%s", tc.numeric, tc.want, sb.String())
+		}
+	}
+}
+
+func TestWriterCommentPercent(t *testing.T) {
+	// The comment wraps right before "%" words
+	text := strings.Repeat("x", 72) + " %a " + strings.Repeat("y", 76) + " %b"
+	input := "1. e4 {" + text + "} e5 *"
+	game, err := NewParser(strings.NewReader(input)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() error: %v", err)
+	}
+
+	out := game.String()
+	if !strings.Contains(out, "\n %a") || !strings.Contains(out, "\n %b") {
+		t.Errorf("\"%%\" words not at line starts, indented:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "%") {
+			t.Errorf("line starts with %%, an escape: %q", line)
+		}
+	}
+
+	again, err := NewParser(strings.NewReader(out)).ParseGame()
+	if err != nil {
+		t.Fatalf("ParseGame() of the output error: %v", err)
+	}
+	if got := strings.Join(strings.Fields(again.Moves.Next.Comment), " "); got != text {
+		t.Errorf("comment read back = %q, want %q", got, text)
+	}
+}
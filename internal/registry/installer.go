@@ -3,6 +3,7 @@ package registry
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
 	"compress/gzip"
 	"context"
 	"crypto/sha256"
@@ -14,6 +15,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -140,26 +143,29 @@ func (i *Installer) Install(ctx context.Context, engineID string) (*InstalledEng
 		}
 	}
 
-	// Validate engine (skip validation for engines that require network - they need config first)
-	if !engine.RequiresNetwork {
-		i.emitProgress(engineID, "validating", "Validating engine")
-		if err := i.validate(ctx, binaryPath); err != nil {
-			i.emitProgress(engineID, "error", err.Error())
-			return nil, err
-		}
+	// Validate engine, with its network loaded through the engine's network option
+	var networkOption string
+	if networkPath != "" {
+		networkOption = engine.NetworkOption
+	}
+	i.emitProgress(engineID, "validating", "Validating engine")
+	if err := i.validate(ctx, binaryPath, networkOption, networkPath); err != nil {
+		i.emitProgress(engineID, "error", err.Error())
+		return nil, err
 	}
 
 	// Save config
 	installed := &InstalledEngine{
-		ID:          engineID,
-		RegistryID:  engineID,
-		Name:        engine.Name,
-		Version:     engine.Version,
-		BinaryPath:  binaryPath,
-		NetworkPath: networkPath,
-		InstalledAt: time.Now().Format(time.RFC3339),
-		BuildKey:    buildKey,
-		NetworkKey:  networkKey,
+		ID:            engineID,
+		RegistryID:    engineID,
+		Name:          engine.Name,
+		Version:       engine.Version,
+		BinaryPath:    binaryPath,
+		NetworkPath:   networkPath,
+		InstalledAt:   time.Now().Format(time.RFC3339),
+		BuildKey:      buildKey,
+		NetworkKey:    networkKey,
+		NetworkOption: networkOption,
 	}
 
 	configPath := filepath.Join(engineDir, "config.toml")
@@ -393,64 +399,69 @@ func (i *Installer) extractTar(archivePath, destDir, binaryName string, gzipped
 	return binaryPath, nil
 }
 
-// installNetwork downloads and verifies a neural network file.
+// installNetwork downloads and verifies the engine's default network.
 func (i *Installer) installNetwork(ctx context.Context, engineID string, engine *EngineDefinition, engineDir string) (string, string, error) {
-	// Find default network or first available
-	var network *Network
-	var networkKey string
-	for key, net := range engine.Networks {
-		if net.Default {
-			network = &net
-			networkKey = key
-			break
-		}
-		if network == nil {
-			network = &net
-			networkKey = key
-		}
-	}
-
-	if network == nil {
+	networkKey, ok := engine.DefaultNetwork()
+	if !ok {
 		return "", "", fmt.Errorf("no network files defined for engine %s", engineID)
 	}
+	network := engine.Networks[networkKey]
+	path := networkPath(engineDir, networkKey, network)
+	if err := i.downloadNetwork(ctx, engineID, network, path); err != nil {
+		return "", "", err
+	}
+	return path, networkKey, nil
+}
 
+// downloadNetwork downloads and verifies a neural network file to path.
+func (i *Installer) downloadNetwork(ctx context.Context, engineID string, network Network, path string) error {
 	// Create networks directory
-	networksDir := filepath.Join(engineDir, "networks")
-	if err := os.MkdirAll(networksDir, 0755); err != nil {
-		return "", "", fmt.Errorf("create networks dir: %w", err)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create networks dir: %w", err)
 	}
 
-	// Determine filename from URL
-	filename := filepath.Base(network.URL)
-	networkPath := filepath.Join(networksDir, filename)
-
 	// Download network file
-	tempFile := filepath.Join(engineDir, "network.tmp")
+	tempFile := path + ".tmp"
 	if err := i.download(ctx, engineID, network.URL, tempFile); err != nil {
 		os.Remove(tempFile)
-		return "", "", err
+		return err
 	}
 
 	// Verify hash
 	i.emitProgress(engineID, "verifying_network", "Verifying network hash")
 	if err := i.verifyHash(tempFile, network.SHA256); err != nil {
 		os.Remove(tempFile)
-		return "", "", err
+		return err
 	}
 
 	// Move to final location
-	if err := os.Rename(tempFile, networkPath); err != nil {
+	if err := os.Rename(tempFile, path); err != nil {
 		os.Remove(tempFile)
-		return "", "", fmt.Errorf("move network file: %w", err)
+		return fmt.Errorf("move network file: %w", err)
 	}
 
-	return networkPath, networkKey, nil
+	return nil
 }
 
-// validate runs the engine and checks for uciok response.
-func (i *Installer) validate(ctx context.Context, binaryPath string) error {
+// Validation timeouts. Loading a large network can take a while, more so on a GPU.
+const (
+	validateTimeout        = 5 * time.Second
+	validateNetworkTimeout = 60 * time.Second
+)
+
+// validate runs the engine and checks for uciok response. If networkOption is
+// set, it also sets it to networkPath and runs a short search, which fails if
+// the engine cannot load the network.
+func (i *Installer) validate(ctx context.Context, binaryPath, networkOption, networkPath string) error {
 	// Use the existing UCI engine code to validate
 	// Import would create a cycle, so we do basic validation here
+	timeout := validateTimeout
+	if networkOption != "" {
+		timeout = validateNetworkTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	cmd := execCommandContext(ctx, binaryPath)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -464,44 +475,58 @@ func (i *Installer) validate(ctx context.Context, binaryPath string) error {
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("%w: failed to start engine: %v", ErrValidationFailed, err)
 	}
+	defer func() {
+		// Give the engine a moment to quit before killing it
+		fmt.Fprintln(stdin, "quit")
+		stdin.Close()
+		time.AfterFunc(time.Second, cancel)
+		cmd.Wait()
+	}()
 
-	// Send uci command
-	fmt.Fprintln(stdin, "uci")
-
-	// Wait for uciok with timeout
-	done := make(chan bool)
-	var uciok bool
-
+	lines := make(chan string)
 	go func() {
-		buf := make([]byte, 4096)
-		for {
-			n, err := stdout.Read(buf)
-			if err != nil {
-				break
-			}
-			if strings.Contains(string(buf[:n]), "uciok") {
-				uciok = true
-				break
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
 			}
 		}
-		done <- true
 	}()
 
-	select {
-	case <-done:
-	case <-time.After(5 * time.Second):
+	// send writes a command and waits for a line starting with token
+	send := func(command, token string) error {
+		fmt.Fprintln(stdin, command)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return fmt.Errorf("%w: engine exited after %q", ErrValidationFailed, command)
+				}
+				if fields := strings.Fields(line); len(fields) > 0 && fields[0] == token {
+					return nil
+				}
+			case <-ctx.Done():
+				return fmt.Errorf("%w: engine did not respond with %s", ErrValidationFailed, token)
+			}
+		}
 	}
 
-	// Cleanup
-	fmt.Fprintln(stdin, "quit")
-	stdin.Close()
-	cmd.Wait()
-
-	if !uciok {
-		return fmt.Errorf("%w: engine did not respond with uciok", ErrValidationFailed)
+	if err := send("uci", "uciok"); err != nil {
+		return err
+	}
+	if networkOption == "" {
+		return nil
 	}
 
-	return nil
+	fmt.Fprintf(stdin, "setoption name %s value %s\n", networkOption, networkPath)
+	if err := send("isready", "readyok"); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdin, "position startpos")
+	return send("go depth 1", "bestmove")
 }
 
 // saveConfig writes the installed engine configuration.
@@ -558,18 +583,12 @@ func (i *Installer) ListInstalled() ([]InstalledEngine, error) {
 			continue
 		}
 
-		configPath := filepath.Join(i.installDir, entry.Name(), "config.toml")
-		data, err := os.ReadFile(configPath)
+		eng, err := i.loadConfig(entry.Name())
 		if err != nil {
 			continue
 		}
 
-		var eng InstalledEngine
-		if err := toml.Unmarshal(data, &eng); err != nil {
-			continue
-		}
-
-		installed = append(installed, eng)
+		installed = append(installed, *eng)
 	}
 
 	return installed, nil
@@ -577,12 +596,18 @@ func (i *Installer) ListInstalled() ([]InstalledEngine, error) {
 
 // GetInstalled returns an installed engine by ID.
 func (i *Installer) GetInstalled(engineID string) (*InstalledEngine, error) {
-	configPath := filepath.Join(i.installDir, engineID, "config.toml")
-	data, err := os.ReadFile(configPath)
+	eng, err := i.loadConfig(engineID)
+	if os.IsNotExist(err) {
+		return nil, ErrEngineNotFound
+	}
+	return eng, err
+}
+
+// loadConfig reads an installed engine's config. Configs written before the
+// network option was recorded take it from the registry.
+func (i *Installer) loadConfig(engineID string) (*InstalledEngine, error) {
+	data, err := os.ReadFile(filepath.Join(i.installDir, engineID, "config.toml"))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, ErrEngineNotFound
-		}
 		return nil, err
 	}
 
@@ -591,9 +616,76 @@ func (i *Installer) GetInstalled(engineID string) (*InstalledEngine, error) {
 		return nil, err
 	}
 
+	if eng.NetworkPath != "" && eng.NetworkOption == "" {
+		if def, err := i.manager.GetEngine(eng.RegistryID); err == nil {
+			eng.NetworkOption = def.NetworkOption
+		}
+	}
 	return &eng, nil
 }
 
+// SetOptionValue saves a UCI option value to an installed engine's config,
+// to be applied each time the engine starts.
+func (i *Installer) SetOptionValue(engineID, name, value string) error {
+	return i.updateConfig(engineID, func(eng *InstalledEngine) {
+		eng.SetOptionValue(name, value)
+	})
+}
+
+// RemoveOptionValue removes a saved UCI option value from an installed engine's config.
+func (i *Installer) RemoveOptionValue(engineID, name string) error {
+	return i.updateConfig(engineID, func(eng *InstalledEngine) {
+		eng.RemoveOptionValue(name)
+	})
+}
+
+// updateConfig loads an installed engine's config, changes it and saves it back.
+func (i *Installer) updateConfig(engineID string, update func(*InstalledEngine)) error {
+	eng, err := i.GetInstalled(engineID)
+	if err != nil {
+		return err
+	}
+	update(eng)
+	return i.saveConfig(filepath.Join(i.installDir, engineID, "config.toml"), eng)
+}
+
+// SetOptionValue sets a saved option value. Setting an option again moves it
+// to the end of the order, so that it is applied after the options it may depend on.
+func (e *InstalledEngine) SetOptionValue(name, value string) {
+	if e.OptionValues == nil {
+		e.OptionValues = make(map[string]string)
+	}
+	e.OptionOrder = e.OptionNames()
+	e.OptionValues[name] = value
+	e.OptionOrder = append(slices.DeleteFunc(e.OptionOrder, func(n string) bool { return n == name }), name)
+}
+
+// RemoveOptionValue removes a saved option value.
+func (e *InstalledEngine) RemoveOptionValue(name string) {
+	delete(e.OptionValues, name)
+	e.OptionOrder = slices.DeleteFunc(e.OptionOrder, func(n string) bool { return n == name })
+}
+
+// OptionNames returns the names of the saved options in the order they are
+// applied. Options missing from OptionOrder, as in configs edited by hand,
+// come last in alphabetical order.
+func (e *InstalledEngine) OptionNames() []string {
+	names := make([]string, 0, len(e.OptionValues))
+	for _, name := range e.OptionOrder {
+		if _, ok := e.OptionValues[name]; ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range e.OptionValues {
+		if !slices.Contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
 // Uninstall removes an installed engine.
 func (i *Installer) Uninstall(engineID string) error {
 	engineDir := filepath.Join(i.installDir, engineID)
@@ -0,0 +1,20 @@
+package registry
+
+import (
+	"os"
+	"syscall"
+)
+
+// freeMemory returns the memory available to new processes in bytes, or zero if unknown.
+// Besides free pages, macOS can hand out speculative and purgeable pages at once.
+func freeMemory() uint64 {
+	var pages uint64
+	for _, name := range []string{"vm.page_free_count", "vm.page_speculative_count", "vm.page_purgeable_count"} {
+		n, err := syscall.SysctlUint32(name)
+		if err != nil {
+			return 0
+		}
+		pages += uint64(n)
+	}
+	return pages * uint64(os.Getpagesize())
+}
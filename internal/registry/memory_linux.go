@@ -0,0 +1,29 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// freeMemory returns the memory available to new processes in bytes, or zero if unknown.
+func freeMemory() uint64 {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// MemAvailable:   12345678 kB
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) >= 2 && string(fields[0]) == "MemAvailable:" {
+			kb, err := strconv.ParseUint(string(fields[1]), 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb << 10
+		}
+	}
+	return 0
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package registry
+
+// freeMemory returns zero, as free memory is not known on this platform.
+func freeMemory() uint64 {
+	return 0
+}
@@ -0,0 +1,243 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var (
+	ErrNetworkNotFound     = errors.New("network not found in registry")
+	ErrNetworkNotInstalled = errors.New("network not installed")
+	ErrNetworkActive       = errors.New("network is in use")
+)
+
+// NetworkInfo describes a registry network and its state for an installed engine.
+type NetworkInfo struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Size        string `json:"size"`
+	GPUMemory   string `json:"gpuMemory"`
+	Default     bool   `json:"default"`
+	Installed   bool   `json:"installed"`
+	Active      bool   `json:"active"` // The network the engine loads on start
+	Path        string `json:"path"`   // Where the network is, or would be, installed
+}
+
+// NetworkKeys returns the keys of the engine's networks in alphabetical order.
+func (e *EngineDefinition) NetworkKeys() []string {
+	keys := make([]string, 0, len(e.Networks))
+	for key := range e.Networks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DefaultNetwork returns the key of the network marked default, or else the
+// first in alphabetical order, and false if the engine has no networks.
+func (e *EngineDefinition) DefaultNetwork() (string, bool) {
+	keys := e.NetworkKeys()
+	for _, key := range keys {
+		if e.Networks[key].Default {
+			return key, true
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// network returns a network of the engine by key.
+func (e *EngineDefinition) network(key string) (Network, error) {
+	net, ok := e.Networks[key]
+	if !ok {
+		return Network{}, fmt.Errorf("%w: %s", ErrNetworkNotFound, key)
+	}
+	return net, nil
+}
+
+// networkPath returns where a network is installed in an engine's directory.
+// Each network gets a directory named by its key, as networks from different
+// sources may share a file name.
+func networkPath(engineDir, key string, net Network) string {
+	return filepath.Join(engineDir, "networks", key, filepath.Base(net.URL))
+}
+
+// networkFile returns the path of an installed engine's network. The active
+// network is wherever the config says, which for engines installed before
+// networks got their own directories is directly in the networks directory.
+func (i *Installer) networkFile(installed *InstalledEngine, key string, net Network) string {
+	if key == installed.NetworkKey && installed.NetworkPath != "" {
+		return installed.NetworkPath
+	}
+	return networkPath(filepath.Join(i.installDir, installed.ID), key, net)
+}
+
+// ListNetworks returns every registry network of an installed engine, in
+// alphabetical order, with whether it is installed and which one is active.
+func (i *Installer) ListNetworks(engineID string) ([]NetworkInfo, error) {
+	installed, def, err := i.installedDefinition(engineID)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]NetworkInfo, 0, len(def.Networks))
+	for _, key := range def.NetworkKeys() {
+		net := def.Networks[key]
+		path := i.networkFile(installed, key, net)
+		_, err := os.Stat(path)
+		networks = append(networks, NetworkInfo{
+			Key:         key,
+			Description: net.Description,
+			Size:        net.Size,
+			GPUMemory:   net.GPUMemory,
+			Default:     net.Default,
+			Installed:   err == nil,
+			Active:      key == installed.NetworkKey,
+			Path:        path,
+		})
+	}
+	return networks, nil
+}
+
+// InstallNetwork downloads and verifies another network for an installed
+// engine, without making it active. Installing a network again replaces it.
+func (i *Installer) InstallNetwork(ctx context.Context, engineID, key string) error {
+	installed, def, err := i.installedDefinition(engineID)
+	if err != nil {
+		return err
+	}
+	net, err := def.network(key)
+	if err != nil {
+		return err
+	}
+
+	i.emitProgress(engineID, "downloading_network", "Downloading neural network "+key)
+	if err := i.downloadNetwork(ctx, engineID, net, i.networkFile(installed, key, net)); err != nil {
+		i.emitProgress(engineID, "error", err.Error())
+		return err
+	}
+	i.emitProgress(engineID, "done", "Network "+key+" installed")
+	return nil
+}
+
+// VerifyNetwork checks an installed network against its registry hash.
+func (i *Installer) VerifyNetwork(engineID, key string) error {
+	installed, def, err := i.installedDefinition(engineID)
+	if err != nil {
+		return err
+	}
+	path, err := i.installedNetwork(installed, def, key)
+	if err != nil {
+		return err
+	}
+	return i.verifyHash(path, def.Networks[key].SHA256)
+}
+
+// DeleteNetwork removes an installed network. The active network cannot be deleted.
+func (i *Installer) DeleteNetwork(engineID, key string) error {
+	installed, def, err := i.installedDefinition(engineID)
+	if err != nil {
+		return err
+	}
+	path, err := i.installedNetwork(installed, def, key)
+	if err != nil {
+		return err
+	}
+	if key == installed.NetworkKey {
+		return fmt.Errorf("%w: %s", ErrNetworkActive, key)
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	os.Remove(filepath.Dir(path)) // The network's directory, if now empty
+	return nil
+}
+
+// SetActiveNetwork makes an installed network the one the engine loads on start
+// and returns the updated config. A value saved for the network option by hand
+// is dropped, as it would override the network.
+func (i *Installer) SetActiveNetwork(engineID, key string) (*InstalledEngine, error) {
+	installed, def, err := i.installedDefinition(engineID)
+	if err != nil {
+		return nil, err
+	}
+	path, err := i.installedNetwork(installed, def, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The network made inactive must be found where inactive networks are
+	if key != installed.NetworkKey {
+		if err := i.moveInactiveNetwork(installed, def); err != nil {
+			return nil, err
+		}
+	}
+
+	var updated *InstalledEngine
+	err = i.updateConfig(engineID, func(eng *InstalledEngine) {
+		eng.NetworkKey = key
+		eng.NetworkPath = path
+		eng.NetworkOption = def.NetworkOption
+		eng.RemoveOptionValue(def.NetworkOption)
+		updated = eng
+	})
+	return updated, err
+}
+
+// moveInactiveNetwork moves the active network, if it is installed somewhere
+// else, to the place networkPath gives, where it is found once inactive.
+func (i *Installer) moveInactiveNetwork(installed *InstalledEngine, def *EngineDefinition) error {
+	net, ok := def.Networks[installed.NetworkKey]
+	if !ok || installed.NetworkPath == "" {
+		return nil
+	}
+	path := networkPath(filepath.Join(i.installDir, installed.ID), installed.NetworkKey, net)
+	if path == installed.NetworkPath {
+		return nil
+	}
+	if _, err := os.Stat(installed.NetworkPath); err != nil {
+		return nil // Already gone; nothing to keep
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create networks dir: %w", err)
+	}
+	if err := os.Rename(installed.NetworkPath, path); err != nil {
+		return fmt.Errorf("move network file: %w", err)
+	}
+	return nil
+}
+
+// installedDefinition returns an installed engine's config and registry definition.
+func (i *Installer) installedDefinition(engineID string) (*InstalledEngine, *EngineDefinition, error) {
+	installed, err := i.GetInstalled(engineID)
+	if err != nil {
+		return nil, nil, err
+	}
+	def, err := i.manager.GetEngine(installed.RegistryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return installed, def, nil
+}
+
+// installedNetwork returns the path of an installed network of an engine.
+func (i *Installer) installedNetwork(installed *InstalledEngine, def *EngineDefinition, key string) (string, error) {
+	net, err := def.network(key)
+	if err != nil {
+		return "", err
+	}
+	path := i.networkFile(installed, key, net)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", ErrNetworkNotInstalled, key)
+		}
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,181 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrUnresolvable    = errors.New("cannot resolve auto value")
+)
+
+// AutoValue marks an option value to be chosen for the host.
+const AutoValue = "auto"
+
+// RecommendedProfile is the name of the profile made of the options' recommended
+// values, available for every engine that does not define a profile by that name.
+const RecommendedProfile = "recommended"
+
+// Resources "auto" option values are resolved from, as named by OptionDef.Auto.
+const (
+	AutoThreads = "threads" // The logical CPUs less a reserve
+	AutoHash    = "hash"    // A power of two megabytes of about half the free memory
+)
+
+// standardAuto tells what "auto" means for the standard UCI options, unless
+// the registry says otherwise.
+var standardAuto = map[string]string{"Threads": AutoThreads, "Hash": AutoHash}
+
+const (
+	// reservedCPUs are left to the GUI and the system when threads are "auto".
+	reservedCPUs = 1
+	// hashMemoryFraction is the share of free memory given to the hash when it is "auto".
+	hashMemoryFraction = 0.5
+	// fallbackHashMB is the hash for "auto" when neither the free memory nor
+	// the engine's default is known.
+	fallbackHashMB = 64
+)
+
+// HostResources describes the machine "auto" values are resolved for.
+type HostResources struct {
+	LogicalCPUs  int
+	FreeMemoryMB int // Zero if unknown
+}
+
+// DetectHostResources returns the resources of the current machine.
+func DetectHostResources() HostResources {
+	return HostResources{
+		LogicalCPUs:  runtime.NumCPU(),
+		FreeMemoryMB: int(freeMemory() >> 20),
+	}
+}
+
+// ResolveAuto returns the value of an "auto" option resolved from the given
+// resource, AutoThreads or AutoHash. AutoHash fails if the free memory is unknown.
+func (h HostResources) ResolveAuto(resource string) (string, error) {
+	switch resource {
+	case AutoThreads:
+		return strconv.Itoa(max(h.LogicalCPUs-reservedCPUs, 1)), nil
+	case AutoHash:
+		if h.FreeMemoryMB <= 0 {
+			return "", fmt.Errorf("%w: %s: free memory unknown", ErrUnresolvable, resource)
+		}
+		mb := max(uint(float64(h.FreeMemoryMB)*hashMemoryFraction), 1)
+		return strconv.FormatUint(1<<(bits.Len(mb)-1), 10), nil
+	default:
+		return "", fmt.Errorf("%w: resource %q", ErrUnresolvable, resource)
+	}
+}
+
+// autoResource returns what an option's "auto" value is resolved from, or ""
+// if it cannot be "auto".
+func (e *EngineDefinition) autoResource(option string) string {
+	if r := e.Options[option].Auto; r != "" {
+		return r
+	}
+	return standardAuto[option]
+}
+
+// resolveAuto returns the value of an option set to "auto" for the host. If
+// the free memory is unknown, a hash gets the engine's default, or failing that
+// a small fixed size, rather than failing the profile.
+func (e *EngineDefinition) resolveAuto(option string, host HostResources) (string, error) {
+	resource := e.autoResource(option)
+	if resource == "" {
+		return "", fmt.Errorf("%w: %s", ErrUnresolvable, option)
+	}
+	if resource == AutoHash && host.FreeMemoryMB <= 0 {
+		if def, ok := e.Options[option].Default.(int64); ok && def > 0 {
+			return strconv.FormatInt(def, 10), nil
+		}
+		return strconv.Itoa(fallbackHashMB), nil
+	}
+	return host.ResolveAuto(resource)
+}
+
+// OptionSetting is an option value taken from a profile.
+type OptionSetting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Auto  bool   `json:"auto"` // Resolved from "auto" for the host
+}
+
+// ResolveProfile returns the option values of a profile, with "auto" values
+// resolved for the host, in the order to apply them: options resolved from the
+// thread count first, as engines may size other options per thread, then the
+// rest by name.
+func (e *EngineDefinition) ResolveProfile(name string, host HostResources) ([]OptionSetting, error) {
+	profile, ok := e.Profiles[name]
+	if !ok && name == RecommendedProfile {
+		profile, ok = e.recommendedProfile(), true
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	names := make([]string, 0, len(profile))
+	for option := range profile {
+		names = append(names, option)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ti, tj := e.autoResource(names[i]) == AutoThreads, e.autoResource(names[j]) == AutoThreads
+		if ti != tj {
+			return ti
+		}
+		return names[i] < names[j]
+	})
+
+	settings := make([]OptionSetting, 0, len(names))
+	for _, option := range names {
+		s := OptionSetting{Name: option}
+		switch v := profile[option].(type) {
+		case string:
+			s.Value = v
+			if v == AutoValue {
+				var err error
+				if s.Value, err = e.resolveAuto(option, host); err != nil {
+					return nil, err
+				}
+				s.Auto = true
+			}
+		case int64:
+			s.Value = strconv.FormatInt(v, 10)
+		case bool:
+			s.Value = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("profile %s: option %s has unsupported value %v", name, option, v)
+		}
+		settings = append(settings, s)
+	}
+	return settings, nil
+}
+
+// ProfileNames returns the names of the engine's profiles in alphabetical order,
+// including RecommendedProfile if any option has a recommended value.
+func (e *EngineDefinition) ProfileNames() []string {
+	names := make([]string, 0, len(e.Profiles)+1)
+	for name := range e.Profiles {
+		names = append(names, name)
+	}
+	if _, ok := e.Profiles[RecommendedProfile]; !ok && len(e.recommendedProfile()) > 0 {
+		names = append(names, RecommendedProfile)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// recommendedProfile builds a profile from the options' recommended values.
+func (e *EngineDefinition) recommendedProfile() Profile {
+	profile := make(Profile)
+	for option, def := range e.Options {
+		if def.Recommended != nil {
+			profile[option] = def.Recommended
+		}
+	}
+	return profile
+}
@@ -65,6 +65,15 @@ func (m *Manager) validate(reg *Registry) error {
 		if len(engine.Builds) == 0 {
 			return fmt.Errorf("%w: engine %s has no builds", ErrInvalidRegistry, id)
 		}
+		if len(engine.Networks) > 0 && engine.NetworkOption == "" {
+			return fmt.Errorf("%w: engine %s has networks but no network_option", ErrInvalidRegistry, id)
+		}
+
+		for name, opt := range engine.Options {
+			if opt.Auto != "" && opt.Auto != AutoThreads && opt.Auto != AutoHash {
+				return fmt.Errorf("%w: engine %s option %s has unknown auto %q", ErrInvalidRegistry, id, name, opt.Auto)
+			}
+		}
 
 		for buildKey, build := range engine.Builds {
 			if build.URL == "" {
@@ -173,14 +182,15 @@ func (m *Manager) GetBuildURL(engineID string) (string, string, error) {
 
 // EngineInfo returns summary info for display.
 type EngineInfo struct {
-	ID              string `json:"id"`
-	Name            string `json:"name"`
-	Version         string `json:"version"`
-	Author          string `json:"author"`
-	Description     string `json:"description"`
-	ELOEstimate     int    `json:"eloEstimate"`
-	RequiresNetwork bool   `json:"requiresNetwork"`
-	HasBuild        bool   `json:"hasBuild"` // Whether a compatible build exists
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	Author          string   `json:"author"`
+	Description     string   `json:"description"`
+	ELOEstimate     int      `json:"eloEstimate"`
+	RequiresNetwork bool     `json:"requiresNetwork"`
+	HasBuild        bool     `json:"hasBuild"` // Whether a compatible build exists
+	Profiles        []string `json:"profiles"` // Option presets for ApplyProfile
 }
 
 // ListEngineInfo returns display-friendly info for all engines.
@@ -201,6 +211,7 @@ func (m *Manager) ListEngineInfo() []EngineInfo {
 			ELOEstimate:     e.ELOEstimate,
 			RequiresNetwork: e.RequiresNetwork,
 			HasBuild:        err == nil,
+			Profiles:        e.ProfileNames(),
 		})
 	}
 	return infos
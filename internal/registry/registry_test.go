@@ -1,6 +1,19 @@
 package registry
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -251,6 +264,39 @@ version = "1.0.0"
 name = "Test"
 [engines.test.builds.linux-amd64]
 url = "https://example.com/test.tar"
+`,
+			wantErr: true,
+		},
+		{
+			name: "unknown auto resource",
+			toml: `
+[meta]
+version = "1.0.0"
+[engines.test]
+name = "Test"
+[engines.test.builds.linux-amd64]
+url = "https://example.com/test.tar"
+sha256 = "hash"
+[engines.test.options.Cache]
+type = "spin"
+auto = "disk"
+`,
+			wantErr: true,
+		},
+		{
+			name: "networks without network option",
+			toml: `
+[meta]
+version = "1.0.0"
+[engines.test]
+name = "Test"
+requires_network = true
+[engines.test.builds.linux-amd64]
+url = "https://example.com/test.tar"
+sha256 = "hash"
+[engines.test.networks.small]
+url = "https://example.com/small.pb.gz"
+sha256 = "hash"
 `,
 			wantErr: true,
 		},
@@ -389,3 +435,420 @@ sha256 = "hash"
 		t.Error("no-build engine should have HasBuild=false")
 	}
 }
+
+func TestSavedOptionValues(t *testing.T) {
+	dir := t.TempDir()
+	inst := &Installer{installDir: dir}
+	if err := os.MkdirAll(filepath.Join(dir, "sf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A config written before option_order existed
+	config := `id = "sf"
+binary_path = "/opt/sf/stockfish"
+
+[options]
+Threads = "4"
+Hash = "256"
+`
+	if err := os.WriteFile(filepath.Join(dir, "sf", "config.toml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name, value string // Empty value removes the option
+		want        string
+	}{
+		{"SyzygyPath", "/tb", "Hash=256,Threads=4,SyzygyPath=/tb"},
+		{"Hash", "1024", "Threads=4,SyzygyPath=/tb,Hash=1024"},
+		{"Threads", "", "SyzygyPath=/tb,Hash=1024"},
+	}
+	for _, step := range steps {
+		var err error
+		if step.value == "" {
+			err = inst.RemoveOptionValue("sf", step.name)
+		} else {
+			err = inst.SetOptionValue("sf", step.name, step.value)
+		}
+		if err != nil {
+			t.Fatalf("saving %s: %v", step.name, err)
+		}
+
+		eng, err := inst.GetInstalled("sf")
+		if err != nil {
+			t.Fatalf("GetInstalled() error: %v", err)
+		}
+		var got []string
+		for _, name := range eng.OptionNames() {
+			got = append(got, name+"="+eng.OptionValues[name])
+		}
+		if strings.Join(got, ",") != step.want {
+			t.Errorf("after %s, options = %s, want %s", step.name, strings.Join(got, ","), step.want)
+		}
+		if eng.BinaryPath != "/opt/sf/stockfish" {
+			t.Errorf("BinaryPath = %q, lost on save", eng.BinaryPath)
+		}
+	}
+
+	if err := inst.SetOptionValue("missing", "Hash", "16"); err != ErrEngineNotFound {
+		t.Errorf("SetOptionValue() on missing engine error = %v, want ErrEngineNotFound", err)
+	}
+}
+
+// fakeNetworkEngine answers like an engine that exits when searching without
+// a readable WeightsFile.
+const fakeNetworkEngine = `#!/bin/sh
+net=
+while read -r cmd rest; do
+	case "$cmd" in
+	uci) echo "id name Fake"; echo uciok ;;
+	setoption) net=${rest#name WeightsFile value } ;;
+	isready) echo readyok ;;
+	go) [ -f "$net" ] || exit 1; echo "bestmove e2e4" ;;
+	quit) exit 0 ;;
+	esac
+done
+`
+
+func TestValidateNetwork(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake engine is a shell script")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "engine")
+	if err := os.WriteFile(binary, []byte(fakeNetworkEngine), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	net := filepath.Join(dir, "net with spaces.pb.gz")
+	if err := os.WriteFile(net, []byte("weights"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		option, path string
+		wantErr      bool
+	}{
+		{"without network", "", "", false},
+		{"network loaded", "WeightsFile", net, false},
+		{"network missing", "WeightsFile", filepath.Join(dir, "missing.pb.gz"), true},
+	}
+
+	inst := &Installer{installDir: dir}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := inst.validate(context.Background(), binary, tc.option, tc.path)
+			if tc.wantErr && !errors.Is(err, ErrValidationFailed) {
+				t.Errorf("validate() error = %v, want ErrValidationFailed", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("validate() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNetworkOptionFromRegistry(t *testing.T) {
+	mgr := NewManager("", CPUFeatures{})
+	if err := mgr.LoadFromEmbed([]byte(`
+[meta]
+version = "1.0.0"
+[engines.lc0]
+name = "Lc0"
+requires_network = true
+network_option = "WeightsFile"
+[engines.lc0.builds.linux-amd64]
+url = "https://example.com/lc0.tar"
+sha256 = "hash"
+`)); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	inst := &Installer{manager: mgr, installDir: dir}
+	if err := os.MkdirAll(filepath.Join(dir, "lc0"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A config written before network_option existed
+	config := `id = "lc0"
+registry_id = "lc0"
+network_path = "/opt/lc0/networks/bt4.pb.gz"
+`
+	if err := os.WriteFile(filepath.Join(dir, "lc0", "config.toml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	eng, err := inst.GetInstalled("lc0")
+	if err != nil {
+		t.Fatalf("GetInstalled() error: %v", err)
+	}
+	if eng.NetworkOption != "WeightsFile" {
+		t.Errorf("NetworkOption = %q, want WeightsFile", eng.NetworkOption)
+	}
+}
+
+func TestDefaultNetwork(t *testing.T) {
+	def := EngineDefinition{Networks: map[string]Network{"c": {}, "a": {}, "b": {}}}
+	for range 10 {
+		if key, ok := def.DefaultNetwork(); key != "a" || !ok {
+			t.Fatalf("DefaultNetwork() = %q, %v, want a, true", key, ok)
+		}
+	}
+	def.Networks["b"] = Network{Default: true}
+	if key, _ := def.DefaultNetwork(); key != "b" {
+		t.Errorf("DefaultNetwork() = %q, want the default b", key)
+	}
+	if _, ok := (&EngineDefinition{}).DefaultNetwork(); ok {
+		t.Error("DefaultNetwork() without networks = true")
+	}
+}
+
+func TestManageNetworks(t *testing.T) {
+	// Both networks have the same file name
+	weights := map[string]string{"/small.pb.gz": "small weights", "/mirror/small.pb.gz": "big weights"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, weights[r.URL.Path])
+	}))
+	defer server.Close()
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	mgr := NewManager("", CPUFeatures{})
+	if err := mgr.LoadFromEmbed([]byte(fmt.Sprintf(`
+[meta]
+version = "1.0.0"
+[engines.lc0]
+name = "Lc0"
+requires_network = true
+network_option = "WeightsFile"
+[engines.lc0.builds.linux-amd64]
+url = "https://example.com/lc0.tar"
+sha256 = "hash"
+[engines.lc0.networks.small]
+url = "%[1]s/small.pb.gz"
+sha256 = "%[2]s"
+default = true
+[engines.lc0.networks.big]
+url = "%[1]s/mirror/small.pb.gz"
+sha256 = "%[3]s"
+`, server.URL, hash(weights["/small.pb.gz"]), hash(weights["/mirror/small.pb.gz"])))); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	inst := &Installer{manager: mgr, httpClient: server.Client(), installDir: dir}
+	// Installed before networks got a directory each
+	small := filepath.Join(dir, "lc0", "networks", "small.pb.gz")
+	if err := os.MkdirAll(filepath.Dir(small), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(small, []byte(weights["/small.pb.gz"]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf(`id = "lc0"
+registry_id = "lc0"
+network_key = "small"
+network_path = %q
+network_option = "WeightsFile"
+
+[options]
+WeightsFile = "/elsewhere/custom.pb.gz"
+Threads = "2"
+`, small)
+	if err := os.WriteFile(filepath.Join(dir, "lc0", "config.toml"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	status := func() string {
+		networks, err := inst.ListNetworks("lc0")
+		if err != nil {
+			t.Fatalf("ListNetworks() error: %v", err)
+		}
+		var got []string
+		for _, n := range networks {
+			got = append(got, fmt.Sprintf("%s:%v:%v", n.Key, n.Installed, n.Active))
+		}
+		return strings.Join(got, ",")
+	}
+	if got, want := status(), "big:false:false,small:true:true"; got != want {
+		t.Errorf("networks = %s, want %s", got, want)
+	}
+
+	// Selecting the active network again leaves it where it is
+	eng, err := inst.SetActiveNetwork("lc0", "small")
+	if err != nil {
+		t.Fatalf("SetActiveNetwork() of the active network error: %v", err)
+	}
+	if _, err := os.Stat(eng.NetworkPath); err != nil || eng.NetworkPath != small {
+		t.Errorf("active network at %s (%v), want %s", eng.NetworkPath, err, small)
+	}
+
+	if _, err := inst.SetActiveNetwork("lc0", "big"); !errors.Is(err, ErrNetworkNotInstalled) {
+		t.Errorf("SetActiveNetwork() before install error = %v, want ErrNetworkNotInstalled", err)
+	}
+	if err := inst.InstallNetwork(context.Background(), "lc0", "big"); err != nil {
+		t.Fatalf("InstallNetwork() error: %v", err)
+	}
+	if err := inst.VerifyNetwork("lc0", "big"); err != nil {
+		t.Errorf("VerifyNetwork() error: %v", err)
+	}
+
+	eng, err = inst.SetActiveNetwork("lc0", "big")
+	if err != nil {
+		t.Fatalf("SetActiveNetwork() error: %v", err)
+	}
+	if eng.NetworkKey != "big" || eng.NetworkPath == small {
+		t.Errorf("active network = %s at %s, want big", eng.NetworkKey, eng.NetworkPath)
+	}
+	if _, ok := eng.OptionValues["WeightsFile"]; ok || eng.OptionValues["Threads"] != "2" {
+		t.Errorf("options = %v, want only the saved WeightsFile dropped", eng.OptionValues)
+	}
+	if got, want := status(), "big:true:true,small:true:false"; got != want {
+		t.Errorf("networks = %s, want %s", got, want)
+	}
+
+	if err := inst.DeleteNetwork("lc0", "big"); !errors.Is(err, ErrNetworkActive) {
+		t.Errorf("DeleteNetwork() of the active network error = %v, want ErrNetworkActive", err)
+	}
+	// The network installed where networks were before they got a directory each
+	// is moved into its own once inactive
+	small = filepath.Join(dir, "lc0", "networks", "small", "small.pb.gz")
+	if err := os.WriteFile(small, []byte("corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := inst.VerifyNetwork("lc0", "small"); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("VerifyNetwork() of a corrupt network error = %v, want ErrHashMismatch", err)
+	}
+	if err := inst.DeleteNetwork("lc0", "small"); err != nil {
+		t.Errorf("DeleteNetwork() error: %v", err)
+	}
+	if err := inst.VerifyNetwork("lc0", "big"); err != nil {
+		t.Errorf("VerifyNetwork() after deleting the other network error: %v", err)
+	}
+	if got, want := status(), "big:true:true,small:false:false"; got != want {
+		t.Errorf("networks = %s, want %s", got, want)
+	}
+	if err := inst.InstallNetwork(context.Background(), "lc0", "huge"); !errors.Is(err, ErrNetworkNotFound) {
+		t.Errorf("InstallNetwork() of an unknown network error = %v, want ErrNetworkNotFound", err)
+	}
+}
+
+func TestResolveAuto(t *testing.T) {
+	host := HostResources{LogicalCPUs: 8, FreeMemoryMB: 6000}
+	tests := []struct {
+		host     HostResources
+		resource string
+		want     string
+		wantErr  bool
+	}{
+		{host, AutoThreads, "7", false},
+		{HostResources{LogicalCPUs: 1}, AutoThreads, "1", false},
+		{host, AutoHash, "2048", false},
+		{HostResources{LogicalCPUs: 8, FreeMemoryMB: 1}, AutoHash, "1", false},
+		{HostResources{LogicalCPUs: 8}, AutoHash, "", true},
+		{host, "Threads", "", true},
+	}
+	for _, tc := range tests {
+		got, err := tc.host.ResolveAuto(tc.resource)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("%+v.ResolveAuto(%s) = %q, %v, want %q", tc.host, tc.resource, got, err, tc.want)
+		}
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	mgr := NewManager("", CPUFeatures{})
+	if err := mgr.LoadFromEmbed([]byte(`
+[meta]
+version = "1.0.0"
+
+[engines.sf]
+name = "Stockfish"
+
+[engines.sf.builds.linux-amd64]
+url = "https://example.com/sf.tar"
+sha256 = "hash"
+
+[engines.sf.options.Hash]
+type = "spin"
+recommended = 256
+
+[engines.sf.options.Threads]
+type = "spin"
+recommended = "auto"
+
+[engines.sf.options.Helpers]
+type = "spin"
+auto = "threads"
+
+[engines.sf.profiles.analysis]
+Hash = "auto"
+Helpers = "auto"
+MultiPV = 3
+Threads = "auto"
+UCI_ShowWDL = true
+
+[engines.sf.profiles.bad]
+MultiPV = "auto"
+`)); err != nil {
+		t.Fatalf("LoadFromEmbed() error: %v", err)
+	}
+	eng, err := mgr.GetEngine("sf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := HostResources{LogicalCPUs: 4, FreeMemoryMB: 4096}
+
+	if got := strings.Join(eng.ProfileNames(), ","); got != "analysis,bad,recommended" {
+		t.Errorf("ProfileNames() = %s, want analysis,bad,recommended", got)
+	}
+
+	tests := []struct {
+		profile string
+		host    HostResources
+		want    []OptionSetting
+	}{
+		{"analysis", host, []OptionSetting{
+			{Name: "Helpers", Value: "3", Auto: true},
+			{Name: "Threads", Value: "3", Auto: true},
+			{Name: "Hash", Value: "2048", Auto: true},
+			{Name: "MultiPV", Value: "3"},
+			{Name: "UCI_ShowWDL", Value: "true"},
+		}},
+		{"analysis", HostResources{LogicalCPUs: 4}, []OptionSetting{
+			{Name: "Helpers", Value: "3", Auto: true},
+			{Name: "Threads", Value: "3", Auto: true},
+			{Name: "Hash", Value: "64", Auto: true}, // Free memory unknown
+			{Name: "MultiPV", Value: "3"},
+			{Name: "UCI_ShowWDL", Value: "true"},
+		}},
+		{"recommended", host, []OptionSetting{
+			{Name: "Threads", Value: "3", Auto: true},
+			{Name: "Hash", Value: "256"},
+		}},
+	}
+	for _, tc := range tests {
+		got, err := eng.ResolveProfile(tc.profile, tc.host)
+		if err != nil {
+			t.Errorf("ResolveProfile(%s) error: %v", tc.profile, err)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ResolveProfile(%s) = %+v, want %+v", tc.profile, got, tc.want)
+		}
+	}
+
+	if _, err := eng.ResolveProfile("blitz", host); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("ResolveProfile(blitz) error = %v, want ErrProfileNotFound", err)
+	}
+	if _, err := eng.ResolveProfile("bad", host); !errors.Is(err, ErrUnresolvable) {
+		t.Errorf("ResolveProfile(bad) error = %v, want ErrUnresolvable", err)
+	}
+
+	eng.Options["Hash"] = OptionDef{Type: "spin", Default: int64(16)}
+	got, err := eng.ResolveProfile("analysis", HostResources{LogicalCPUs: 4})
+	if err != nil || got[2] != (OptionSetting{Name: "Hash", Value: "16", Auto: true}) {
+		t.Errorf("ResolveProfile() without free memory = %+v, %v, want the default Hash", got, err)
+	}
+}
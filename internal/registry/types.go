@@ -22,6 +22,7 @@ type EngineDefinition struct {
 	Description     string               `toml:"description"`
 	ELOEstimate     int                  `toml:"elo_estimate"`
 	RequiresNetwork bool                 `toml:"requires_network"`
+	NetworkOption   string               `toml:"network_option"` // UCI option set to the network's path, e.g. "WeightsFile"
 	Builds          map[string]Build     `toml:"builds"`
 	Networks        map[string]Network   `toml:"networks"`
 	Options         map[string]OptionDef `toml:"options"`
@@ -55,6 +56,7 @@ type OptionDef struct {
 	Max         *int   `toml:"max"`
 	Description string `toml:"description"`
 	Recommended any    `toml:"recommended"` // Can be int, string, or "auto"
+	Auto        string `toml:"auto"`        // What "auto" resolves from: "threads" or "hash"; known for Threads and Hash
 }
 
 // Profile is a named configuration preset.
@@ -71,14 +73,16 @@ type CPUFeatures struct {
 
 // InstalledEngine represents a locally installed engine.
 type InstalledEngine struct {
-	ID           string            `toml:"id"`
-	RegistryID   string            `toml:"registry_id"`
-	Name         string            `toml:"name"`
-	Version      string            `toml:"version"`
-	BinaryPath   string            `toml:"binary_path"`
-	NetworkPath  string            `toml:"network_path"` // Path to installed neural network (if any)
-	InstalledAt  string            `toml:"installed_at"`
-	BuildKey     string            `toml:"build_key"`
-	NetworkKey   string            `toml:"network_key"` // Which network was installed
-	OptionValues map[string]string `toml:"options"`
+	ID            string            `toml:"id"`
+	RegistryID    string            `toml:"registry_id"`
+	Name          string            `toml:"name"`
+	Version       string            `toml:"version"`
+	BinaryPath    string            `toml:"binary_path"`
+	NetworkPath   string            `toml:"network_path"` // Path to installed neural network (if any)
+	InstalledAt   string            `toml:"installed_at"`
+	BuildKey      string            `toml:"build_key"`
+	NetworkKey    string            `toml:"network_key"`    // Which network was installed
+	NetworkOption string            `toml:"network_option"` // UCI option NetworkPath is set to on start
+	OptionValues  map[string]string `toml:"options"`
+	OptionOrder   []string          `toml:"option_order"` // Order in which OptionValues are applied
 }
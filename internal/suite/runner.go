@@ -0,0 +1,255 @@
+package suite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"rungine/internal/uci"
+)
+
+const (
+	// readyTimeout bounds the isready handshake before each position.
+	readyTimeout = 10 * time.Second
+	// moveTimeSlack is added to the movetime when no explicit timeout is configured.
+	moveTimeSlack = 10 * time.Second
+)
+
+// Config controls how a suite is searched.
+type Config struct {
+	// Limit is used for every position; MoveTime, Depth or Nodes must be set.
+	Limit uci.GoParams
+
+	// Instances is the number of processes run in parallel per engine (default 1).
+	Instances int
+
+	// Depths lists depths at which the first PV move is also checked.
+	Depths []int
+
+	// Timeout bounds the search of a single position. When zero, movetime searches
+	// get the movetime plus some slack and depth or nodes searches are unbounded.
+	Timeout time.Duration
+}
+
+// Runner sends test positions to engines registered with an EngineManager.
+// Each engine under test is started as fresh instances registered next to it,
+// with the engine's non-default options copied over, so runs are not affected
+// by analysis going on in the UI.
+type Runner struct {
+	manager  *uci.EngineManager
+	config   Config
+	onResult func(Result)
+	mu       sync.Mutex
+	logger   *slog.Logger
+}
+
+// NewRunner creates a suite runner using engines from manager.
+func NewRunner(manager *uci.EngineManager, config Config) *Runner {
+	if config.Instances <= 0 {
+		config.Instances = 1
+	}
+	return &Runner{
+		manager: manager,
+		config:  config,
+		logger:  slog.Default().With("component", "suite"),
+	}
+}
+
+// SetResultCallback sets a callback invoked as each position finishes.
+// The callback is invoked from worker goroutines; it should be safe for concurrent use.
+func (r *Runner) SetResultCallback(cb func(Result)) {
+	r.mu.Lock()
+	r.onResult = cb
+	r.mu.Unlock()
+}
+
+// Run searches every position with every engine and returns one report per engine,
+// in the order of engineIDs. Errors on single positions are recorded in the results;
+// Run only fails if an engine cannot be started or ctx is cancelled.
+func (r *Runner) Run(ctx context.Context, engineIDs []string, positions []Position) ([]*Report, error) {
+	if len(engineIDs) == 0 {
+		return nil, ErrNoEngines
+	}
+	limit := r.config.Limit
+	if limit.Infinite || (limit.MoveTime <= 0 && limit.Depth <= 0 && limit.Nodes <= 0) {
+		return nil, ErrNoLimit
+	}
+
+	// Resolve every engine before starting any worker
+	bases := make([]*uci.Engine, len(engineIDs))
+	for i, id := range engineIDs {
+		base, err := r.manager.GetEngine(id)
+		if err != nil {
+			return nil, err
+		}
+		bases[i] = base
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reports := make([]*Report, len(engineIDs))
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		errMu.Unlock()
+	}
+
+	for i, id := range engineIDs {
+		base := bases[i]
+		report := &Report{EngineID: id, Results: make([]Result, len(positions))}
+		reports[i] = report
+
+		jobs := make(chan int, len(positions))
+		for j := range positions {
+			jobs <- j
+		}
+		close(jobs)
+
+		for n := 0; n < r.config.Instances; n++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				if err := r.worker(ctx, base, n, positions, jobs, report); err != nil {
+					fail(err)
+				}
+			}(n)
+		}
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, report := range reports {
+		report.tally()
+	}
+	return reports, nil
+}
+
+// worker runs one instance of base and searches positions taken from jobs until none are left.
+func (r *Runner) worker(ctx context.Context, base *uci.Engine, n int, positions []Position, jobs <-chan int, report *Report) error {
+	engine, err := r.startInstance(ctx, base, n)
+	if err != nil {
+		return err
+	}
+	defer r.manager.UnregisterEngine(engine.ID)
+
+	for j := range jobs {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		res := r.search(ctx, engine, positions[j])
+		res.EngineID = base.ID
+		report.Results[j] = res
+
+		r.mu.Lock()
+		cb := r.onResult
+		r.mu.Unlock()
+		if cb != nil {
+			cb(res)
+		}
+
+		if engine.State() != uci.EngineStateReady {
+			// The engine crashed or never answered stop; carry on with a fresh process
+			r.manager.UnregisterEngine(engine.ID)
+			if engine, err = r.startInstance(ctx, base, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// startInstance registers and starts the n-th suite instance of base,
+// copying the options that were changed from their defaults.
+func (r *Runner) startInstance(ctx context.Context, base *uci.Engine, n int) (*uci.Engine, error) {
+	id := fmt.Sprintf("%s/suite-%d", base.ID, n+1)
+	if err := r.manager.RegisterEngine(id, base.BinaryPath); err != nil {
+		return nil, err
+	}
+	engine, err := r.manager.GetEngine(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := engine.Start(ctx); err != nil {
+		r.manager.UnregisterEngine(id)
+		return nil, fmt.Errorf("start engine %s: %w", id, err)
+	}
+
+	for name, opt := range base.Options() {
+		if opt.Type == uci.OptionTypeButton || opt.Value == opt.Default {
+			continue
+		}
+		if err := engine.SetOption(name, opt.Value); err != nil {
+			r.manager.UnregisterEngine(id)
+			return nil, fmt.Errorf("engine %s: set option %s: %w", id, name, err)
+		}
+	}
+	if base.Chess960() {
+		if err := engine.SetChess960(true); err != nil {
+			r.manager.UnregisterEngine(id)
+			return nil, err
+		}
+	}
+
+	r.logger.Info("suite instance started", "id", id)
+	return engine, nil
+}
+
+// search runs one position on engine and checks the result.
+func (r *Runner) search(ctx context.Context, engine *uci.Engine, p Position) Result {
+	failed := func(err error) Result {
+		return Result{PositionID: p.ID, Error: err.Error()}
+	}
+
+	if err := engine.NewGame(); err != nil {
+		return failed(err)
+	}
+	if err := engine.IsReady(readyTimeout); err != nil {
+		return failed(err)
+	}
+
+	searchCtx := ctx
+	if d := r.timeout(); d > 0 {
+		var cancel context.CancelFunc
+		searchCtx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	res, err := engine.Search(searchCtx, p.FEN, nil, r.config.Limit)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return failed(err)
+	}
+	return evaluate(p, engine.ID, res.Infos, res.BestMove, r.config.Depths, res.Elapsed)
+}
+
+// timeout returns the per-position search timeout, or zero for none.
+func (r *Runner) timeout() time.Duration {
+	if r.config.Timeout > 0 {
+		return r.config.Timeout
+	}
+	if r.config.Limit.MoveTime > 0 {
+		return r.config.Limit.MoveTime + moveTimeSlack
+	}
+	return 0
+}
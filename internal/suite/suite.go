@@ -0,0 +1,282 @@
+// Package suite runs EPD test suites against UCI engines and compares the results.
+package suite
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rungine/internal/epd"
+	"rungine/internal/fen"
+	"rungine/internal/uci"
+)
+
+var (
+	ErrNoSolution = errors.New("position has no bm or am operation")
+	ErrNoLimit    = errors.New("suite needs a movetime, depth or nodes limit")
+	ErrNoEngines  = errors.New("no engines to run")
+)
+
+// Position is a test position with the moves that solve or fail it, in UCI notation.
+type Position struct {
+	ID    string
+	FEN   string
+	Best  []string // Any of these solves the position
+	Avoid []string // None of these may be played
+
+	pos *fen.Position
+}
+
+// NewPositions converts EPD records into test positions.
+// Records without an id are numbered by their place in the suite.
+func NewPositions(records []*epd.Record) ([]Position, error) {
+	positions := make([]Position, 0, len(records))
+	for i, rec := range records {
+		id := rec.ID()
+		if id == "" {
+			id = "#" + strconv.Itoa(i+1)
+		}
+
+		pos, err := rec.Position()
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", id, err)
+		}
+		best, err := rec.BestMovesUCI()
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", id, err)
+		}
+		avoid, err := rec.AvoidMovesUCI()
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", id, err)
+		}
+		if len(best) == 0 && len(avoid) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoSolution, id)
+		}
+
+		positions = append(positions, Position{
+			ID:    id,
+			FEN:   pos.String(),
+			Best:  best,
+			Avoid: avoid,
+			pos:   pos,
+		})
+	}
+	return positions, nil
+}
+
+// Solves returns true if playing move solves the position: it is one of the
+// best moves (if any are given) and none of the moves to avoid.
+// Castling is accepted both as king to destination and as king takes rook.
+func (p Position) Solves(move string) bool {
+	move = p.normalize(move)
+	if move == "" || slices.Contains(p.Avoid, move) {
+		return false
+	}
+	return len(p.Best) == 0 || slices.Contains(p.Best, move)
+}
+
+// normalize rewrites a move the way fen.Move.String writes it, or returns "" if it is illegal.
+// Parsing plays moves on the board, so it works on a copy: results of different
+// engines are checked against the same position at once.
+func (p Position) normalize(move string) string {
+	if p.pos == nil {
+		return move
+	}
+	pos := *p.pos
+	m, err := pos.ParseUCIMove(move)
+	if err != nil {
+		return ""
+	}
+	return m.String()
+}
+
+// Result is the outcome of one engine searching one position.
+type Result struct {
+	PositionID string `json:"positionId"`
+	EngineID   string `json:"engineId"`
+	BestMove   string `json:"bestMove"`
+	Solved     bool   `json:"solved"`
+
+	// SolvedTime is the search time from which the first PV move solved the position
+	// until the end of the search (time to solution); SolvedDepth is its depth.
+	SolvedTime  time.Duration `json:"solvedTime"`
+	SolvedDepth int           `json:"solvedDepth"`
+
+	// DepthSolved records, for each of Config.Depths the search reached,
+	// whether the first PV move at that depth solved the position.
+	DepthSolved map[int]bool `json:"depthSolved"`
+
+	Depth int           `json:"depth"`
+	Nodes int64         `json:"nodes"`
+	Time  time.Duration `json:"time"`
+	Score uci.Score     `json:"score"`
+	Error string        `json:"error"`
+}
+
+// evaluate checks a finished search against the position.
+// elapsed is used for the search time when the engine reported none.
+func evaluate(p Position, engineID string, infos []uci.AnalysisInfo, bm uci.BestMove, depths []int, elapsed time.Duration) Result {
+	res := Result{
+		PositionID: p.ID,
+		EngineID:   engineID,
+		BestMove:   bm.Move,
+		Solved:     p.Solves(bm.Move),
+		Time:       elapsed,
+	}
+
+	var since *uci.AnalysisInfo
+	depthMoves := make(map[int]string)
+	for i := range infos {
+		info := &infos[i]
+		if info.MultiPV > 1 || len(info.PV) == 0 {
+			continue
+		}
+		res.Depth, res.Nodes, res.Score = info.Depth, info.Nodes, info.Score
+		if info.Time > 0 {
+			res.Time = info.Time
+		}
+		if info.Score.LowerBound || info.Score.UpperBound {
+			continue
+		}
+
+		depthMoves[info.Depth] = info.PV[0]
+		if !p.Solves(info.PV[0]) {
+			since = nil
+		} else if since == nil {
+			since = info
+		}
+	}
+
+	if res.Solved {
+		res.SolvedTime, res.SolvedDepth = res.Time, res.Depth
+		if since != nil {
+			res.SolvedTime, res.SolvedDepth = since.Time, since.Depth
+		}
+	}
+
+	if len(depths) > 0 {
+		res.DepthSolved = make(map[int]bool)
+		for _, d := range depths {
+			if mv, ok := depthMoves[d]; ok {
+				res.DepthSolved[d] = p.Solves(mv)
+			}
+		}
+	}
+	return res
+}
+
+// Report collects the results of one engine over a whole suite.
+type Report struct {
+	EngineID string   `json:"engineId"`
+	Results  []Result `json:"results"` // In suite order
+
+	Solved      int           `json:"solved"`
+	Errors      int           `json:"errors"`
+	DepthSolved map[int]int   `json:"depthSolved"` // Positions solved at each of Config.Depths
+	SolveTime   time.Duration `json:"solveTime"`   // Sum of SolvedTime over solved positions
+}
+
+// Total returns the number of positions in the report.
+func (r *Report) Total() int {
+	return len(r.Results)
+}
+
+// AverageSolveTime returns the mean time to solution over solved positions.
+func (r *Report) AverageSolveTime() time.Duration {
+	if r.Solved == 0 {
+		return 0
+	}
+	return r.SolveTime / time.Duration(r.Solved)
+}
+
+// tally recomputes the totals from the results.
+func (r *Report) tally() {
+	r.Solved, r.Errors, r.SolveTime = 0, 0, 0
+	r.DepthSolved = make(map[int]int)
+	for _, res := range r.Results {
+		if res.Error != "" {
+			r.Errors++
+		}
+		if res.Solved {
+			r.Solved++
+			r.SolveTime += res.SolvedTime
+		}
+		for d, ok := range res.DepthSolved {
+			if ok {
+				r.DepthSolved[d]++
+			}
+		}
+	}
+}
+
+// Diff is a position the compared engines disagree on.
+type Diff struct {
+	PositionID string
+	Results    []Result // One per report, in report order
+}
+
+// Compare returns the positions solved by some of the reports but not all of them.
+// The reports must come from the same suite.
+func Compare(reports []*Report) []Diff {
+	if len(reports) < 2 {
+		return nil
+	}
+
+	var diffs []Diff
+	for i, first := range reports[0].Results {
+		results := []Result{first}
+		differs := false
+		for _, r := range reports[1:] {
+			if i >= len(r.Results) {
+				return diffs
+			}
+			results = append(results, r.Results[i])
+			if r.Results[i].Solved != first.Solved {
+				differs = true
+			}
+		}
+		if differs {
+			diffs = append(diffs, Diff{PositionID: first.PositionID, Results: results})
+		}
+	}
+	return diffs
+}
+
+// WriteSummary writes a solve count table for the reports followed by the positions they disagree on.
+func WriteSummary(w io.Writer, reports []*Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Engine\tSolved\tAvg time\tErrors")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%s\t%d\n", r.EngineID, r.Solved, r.Total(),
+			r.AverageSolveTime().Round(time.Millisecond), r.Errors)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	diffs := Compare(reports)
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\n%d positions differ:\n", len(diffs))
+	for _, d := range diffs {
+		parts := make([]string, len(d.Results))
+		for i, res := range d.Results {
+			mark := "-"
+			if res.Solved {
+				mark = "+"
+			}
+			parts[i] = fmt.Sprintf("%s %s%s", res.EngineID, mark, res.BestMove)
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", d.PositionID, strings.Join(parts, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
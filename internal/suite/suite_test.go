@@ -0,0 +1,300 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"rungine/internal/epd"
+	"rungine/internal/uci"
+)
+
+func mustPositions(t *testing.T, lines ...string) []Position {
+	t.Helper()
+	var records []*epd.Record
+	for _, line := range lines {
+		rec, err := epd.Parse(line)
+		if err != nil {
+			t.Fatalf("epd.Parse(%q) error: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	positions, err := NewPositions(records)
+	if err != nil {
+		t.Fatalf("NewPositions() error: %v", err)
+	}
+	return positions
+}
+
+func TestNewPositions(t *testing.T) {
+	positions := mustPositions(t,
+		`r3k2r/8/8/8/8/8/8/R3K2R w KQkq - bm O-O; id "castle";`,
+		`4k3/8/8/8/8/8/8/4K2R w K - am Rh8+;`,
+	)
+
+	if positions[0].ID != "castle" || positions[1].ID != "#2" {
+		t.Errorf("IDs = %q, %q, want castle, #2", positions[0].ID, positions[1].ID)
+	}
+	if got := strings.Join(positions[0].Best, " "); got != "e1g1" {
+		t.Errorf("Best = %q, want e1g1", got)
+	}
+	if got := strings.Join(positions[1].Avoid, " "); got != "h1h8" {
+		t.Errorf("Avoid = %q, want h1h8", got)
+	}
+
+	rec, _ := epd.Parse(`4k3/8/8/8/8/8/8/4K2R w K - id "none";`)
+	if _, err := NewPositions([]*epd.Record{rec}); !errors.Is(err, ErrNoSolution) {
+		t.Errorf("NewPositions() without bm/am error = %v, want ErrNoSolution", err)
+	}
+}
+
+func TestPositionSolves(t *testing.T) {
+	positions := mustPositions(t,
+		`r3k2r/8/8/8/8/8/8/R3K2R w KQkq - bm O-O;`,
+		`4k3/8/8/8/8/8/8/4K2R w K - am Rh8+;`,
+	)
+	castle, avoid := positions[0], positions[1]
+
+	tests := []struct {
+		pos  Position
+		move string
+		want bool
+	}{
+		{castle, "e1g1", true},
+		{castle, "e1h1", true}, // king takes rook, as sent in UCI_Chess960 mode
+		{castle, "e1c1", false},
+		{castle, "e1e9", false},
+		{castle, "(none)", false},
+		{avoid, "h1h8", false},
+		{avoid, "h1h7", true},
+	}
+
+	for _, tc := range tests {
+		if got := tc.pos.Solves(tc.move); got != tc.want {
+			t.Errorf("Solves(%q) = %v, want %v", tc.move, got, tc.want)
+		}
+	}
+}
+
+func info(depth int, ms int, pv ...string) uci.AnalysisInfo {
+	cp := 0
+	return uci.AnalysisInfo{
+		Depth: depth,
+		Time:  time.Duration(ms) * time.Millisecond,
+		Nodes: int64(depth) * 1000,
+		Score: uci.Score{Centipawns: &cp},
+		PV:    pv,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := mustPositions(t, `4k3/8/8/8/8/8/8/4K2R w K - bm Rh8+;`)[0]
+
+	bound := info(4, 35, "h1h8")
+	bound.Score.LowerBound = true
+	multiPV := info(5, 40, "h1h8")
+	multiPV.MultiPV = 2
+
+	tests := []struct {
+		name        string
+		infos       []uci.AnalysisInfo
+		bestMove    string
+		solved      bool
+		solvedTime  time.Duration
+		solvedDepth int
+		depthSolved map[int]bool
+	}{
+		{
+			name:        "found and kept",
+			infos:       []uci.AnalysisInfo{info(1, 1, "e1e2"), info(2, 10, "h1h8"), info(3, 30, "h1h8", "e8e7")},
+			bestMove:    "h1h8",
+			solved:      true,
+			solvedTime:  10 * time.Millisecond,
+			solvedDepth: 2,
+			depthSolved: map[int]bool{1: false, 3: true},
+		},
+		{
+			name:        "found, lost and found again",
+			infos:       []uci.AnalysisInfo{info(1, 1, "h1h8"), info(2, 10, "e1e2"), bound, multiPV, info(3, 50, "h1h8")},
+			bestMove:    "h1h8",
+			solved:      true,
+			solvedTime:  50 * time.Millisecond,
+			solvedDepth: 3,
+			depthSolved: map[int]bool{1: true, 3: true},
+		},
+		{
+			name:        "lost at the end",
+			infos:       []uci.AnalysisInfo{info(1, 1, "h1h8"), info(2, 10, "e1e2")},
+			bestMove:    "e1e2",
+			depthSolved: map[int]bool{1: true},
+		},
+		{
+			name:        "no pv",
+			bestMove:    "h1h8",
+			solved:      true,
+			solvedTime:  time.Second,
+			depthSolved: map[int]bool{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := evaluate(p, "sf", tc.infos, uci.BestMove{Move: tc.bestMove}, []int{1, 3, 20}, time.Second)
+			if res.Solved != tc.solved {
+				t.Errorf("Solved = %v, want %v", res.Solved, tc.solved)
+			}
+			if res.SolvedTime != tc.solvedTime || res.SolvedDepth != tc.solvedDepth {
+				t.Errorf("solved at %v depth %d, want %v depth %d", res.SolvedTime, res.SolvedDepth, tc.solvedTime, tc.solvedDepth)
+			}
+			if len(res.DepthSolved) != len(tc.depthSolved) {
+				t.Errorf("DepthSolved = %v, want %v", res.DepthSolved, tc.depthSolved)
+			}
+			for d, want := range tc.depthSolved {
+				if got, ok := res.DepthSolved[d]; !ok || got != want {
+					t.Errorf("DepthSolved[%d] = %v, want %v", d, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	report := func(id string, solved ...bool) *Report {
+		r := &Report{EngineID: id}
+		for i, s := range solved {
+			r.Results = append(r.Results, Result{
+				PositionID: string(rune('a' + i)),
+				EngineID:   id,
+				Solved:     s,
+				SolvedTime: 100 * time.Millisecond,
+			})
+		}
+		r.tally()
+		return r
+	}
+
+	old := report("sf16", true, false, true, false)
+	updated := report("sf17", true, true, false, false)
+
+	if old.Solved != 2 || old.AverageSolveTime() != 100*time.Millisecond {
+		t.Errorf("tally: solved %d, avg %v", old.Solved, old.AverageSolveTime())
+	}
+
+	diffs := Compare([]*Report{old, updated})
+	var ids []string
+	for _, d := range diffs {
+		ids = append(ids, d.PositionID)
+		if len(d.Results) != 2 {
+			t.Errorf("diff %s has %d results, want 2", d.PositionID, len(d.Results))
+		}
+	}
+	if got := strings.Join(ids, ","); got != "b,c" {
+		t.Errorf("Compare() positions = %q, want %q", got, "b,c")
+	}
+
+	var sb strings.Builder
+	if err := WriteSummary(&sb, []*Report{old, updated}); err != nil {
+		t.Fatalf("WriteSummary() error: %v", err)
+	}
+	if !strings.Contains(sb.String(), "2 positions differ") {
+		t.Errorf("WriteSummary() missing diff section:\n%s", sb.String())
+	}
+}
+
+func TestRunUnknownEngine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake engine is a shell script")
+	}
+	// The fake engine leaves a file behind when started
+	binary := filepath.Join(t.TempDir(), "engine")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\ntouch \"$0.started\"\nexec cat >/dev/null\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := uci.NewEngineManager()
+	defer manager.Shutdown()
+	if err := manager.RegisterEngine("known", binary); err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewRunner(manager, Config{Limit: uci.GoParams{Depth: 1}})
+	positions := mustPositions(t, `k7/8/8/8/8/8/8/K6R w - - bm Rh8+; id "1";`)
+	if _, err := runner.Run(context.Background(), []string{"known", "unknown"}, positions); err == nil {
+		t.Fatal("Run() with an unknown engine succeeded")
+	}
+	// No instance of the known engine may be started, even after Run returns
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(binary + ".started"); err == nil {
+		t.Error("Run() started an engine although another was unknown")
+	}
+}
+
+func TestRunEngines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake engine is a shell script")
+	}
+	// Each fake engine plays the same move in every position
+	dir := t.TempDir()
+	fakeEngine := func(name, move string) string {
+		binary := filepath.Join(dir, name)
+		script := `#!/bin/sh
+while read -r cmd; do
+	case "$cmd" in
+	uci) echo "id name ` + name + `"; echo uciok ;;
+	isready) echo readyok ;;
+	go*) echo "info depth 1 score cp 500 time 1 pv ` + move + `"; echo "bestmove ` + move + `" ;;
+	quit) exit 0 ;;
+	esac
+done
+`
+		if err := os.WriteFile(binary, []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return binary
+	}
+
+	manager := uci.NewEngineManager()
+	defer manager.Shutdown()
+	engines := map[string]string{"good": "h1h8", "bad": "h1h7"}
+	for id, move := range engines {
+		if err := manager.RegisterEngine(id, fakeEngine(id, move)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lines = append(lines, fmt.Sprintf(`k7/8/8/8/8/8/8/K6R w - - bm Rh8+; id "%d";`, i+1))
+	}
+	positions := mustPositions(t, lines...)
+
+	runner := NewRunner(manager, Config{Limit: uci.GoParams{Depth: 1}, Instances: 2, Depths: []int{1}})
+	reports, err := runner.Run(context.Background(), []string{"good", "bad"}, positions)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("%d reports, want 2", len(reports))
+	}
+
+	for _, report := range reports {
+		want := 0
+		if report.EngineID == "good" {
+			want = len(positions)
+		}
+		if report.Solved != want || report.Errors != 0 || report.DepthSolved[1] != want {
+			t.Errorf("%s: solved %d (%d at depth 1) with %d errors, want %d", report.EngineID, report.Solved, report.DepthSolved[1], report.Errors, want)
+		}
+		for _, res := range report.Results {
+			if res.EngineID != report.EngineID || res.BestMove != engines[report.EngineID] {
+				t.Errorf("%s: result %+v", report.EngineID, res)
+			}
+		}
+	}
+}
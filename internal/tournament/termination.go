@@ -0,0 +1,173 @@
+// Package tournament implements engine-vs-engine game arbitration and tournaments.
+package tournament
+
+import (
+	"fmt"
+
+	"rungine/internal/fen"
+	"rungine/internal/pgn"
+)
+
+// Termination identifies why a game ended.
+type Termination int
+
+const (
+	TerminationNone Termination = iota
+	TerminationCheckmate
+	TerminationStalemate
+	TerminationInsufficientMaterial
+	TerminationFivefoldRepetition
+	TerminationSeventyFiveMoves
+	TerminationThreefoldRepetition
+	TerminationFiftyMoves
+)
+
+// String returns a human-readable description of the termination.
+func (t Termination) String() string {
+	switch t {
+	case TerminationNone:
+		return "none"
+	case TerminationCheckmate:
+		return "checkmate"
+	case TerminationStalemate:
+		return "stalemate"
+	case TerminationInsufficientMaterial:
+		return "insufficient material"
+	case TerminationFivefoldRepetition:
+		return "fivefold repetition"
+	case TerminationSeventyFiveMoves:
+		return "75-move rule"
+	case TerminationThreefoldRepetition:
+		return "threefold repetition"
+	case TerminationFiftyMoves:
+		return "50-move rule"
+	default:
+		return "unknown"
+	}
+}
+
+// Automatic returns true if the game ends without either player claiming it.
+// Threefold repetition and the 50-move rule only end a game when claimed,
+// although an arbiter for engine games normally applies them straight away.
+func (t Termination) Automatic() bool {
+	return t != TerminationNone && t != TerminationThreefoldRepetition && t != TerminationFiftyMoves
+}
+
+// Outcome describes how a game ended.
+type Outcome struct {
+	Termination Termination
+	Result      string // One of the pgn.Result* constants
+}
+
+// IsOver returns true if the game has ended.
+func (o Outcome) IsOver() bool {
+	return o.Termination != TerminationNone
+}
+
+// GameState tracks a game's position and history for termination detection.
+type GameState struct {
+	pos   *fen.Position
+	moves []string // Moves played, in UCI notation
+	keys  []uint64 // Zobrist key of every position reached, including the start
+}
+
+// NewGameState creates a game starting from the given position.
+func NewGameState(start *fen.Position) *GameState {
+	pos := *start
+	return &GameState{
+		pos:  &pos,
+		keys: []uint64{pos.Key()},
+	}
+}
+
+// Position returns a copy of the current position.
+func (g *GameState) Position() *fen.Position {
+	pos := *g.pos
+	return &pos
+}
+
+// Moves returns the moves played so far in UCI notation.
+func (g *GameState) Moves() []string {
+	return append([]string(nil), g.moves...)
+}
+
+// Play plays a move given in UCI notation.
+func (g *GameState) Play(uci string) error {
+	m, err := g.pos.ParseUCIMove(uci)
+	if err != nil {
+		return fmt.Errorf("move %d: %w", len(g.moves)+1, err)
+	}
+	g.pos.MakeMove(m)
+	g.moves = append(g.moves, m.String())
+	g.keys = append(g.keys, g.pos.Key())
+	return nil
+}
+
+// Repetitions returns how many times the current position has occurred,
+// counting the current occurrence.
+func (g *GameState) Repetitions() int {
+	// Positions before the last capture or pawn move cannot repeat
+	last := len(g.keys) - 1
+	first := last - g.pos.HalfmoveClock
+	if first < 0 {
+		first = 0
+	}
+
+	count := 0
+	for i := last; i >= first; i -= 2 {
+		if g.keys[i] == g.keys[last] {
+			count++
+		}
+	}
+	return count
+}
+
+// Outcome reports whether the game is over in the current position, and why.
+// Checkmate takes precedence over the move-count rules, so a mate delivered on
+// the 50th or 75th move still wins.
+func (g *GameState) Outcome() Outcome {
+	if len(g.pos.LegalMoves()) == 0 {
+		if g.pos.InCheck() {
+			result := pgn.ResultWhiteWins
+			if g.pos.SideToMove == fen.White {
+				result = pgn.ResultBlackWins
+			}
+			return Outcome{Termination: TerminationCheckmate, Result: result}
+		}
+		return draw(TerminationStalemate)
+	}
+
+	if g.pos.InsufficientMaterial() {
+		return draw(TerminationInsufficientMaterial)
+	}
+
+	reps := g.Repetitions()
+	switch {
+	case reps >= 5:
+		return draw(TerminationFivefoldRepetition)
+	case g.pos.HalfmoveClock >= 150:
+		return draw(TerminationSeventyFiveMoves)
+	case reps >= 3:
+		return draw(TerminationThreefoldRepetition)
+	case g.pos.HalfmoveClock >= 100:
+		return draw(TerminationFiftyMoves)
+	}
+
+	return Outcome{Termination: TerminationNone, Result: pgn.ResultOngoing}
+}
+
+func draw(t Termination) Outcome {
+	return Outcome{Termination: t, Result: pgn.ResultDraw}
+}
+
+// DetectTermination replays a move history in UCI notation from start and
+// reports how the game stands after the last move.
+func DetectTermination(start *fen.Position, moves []string) (Outcome, error) {
+	g := NewGameState(start)
+	for _, m := range moves {
+		if err := g.Play(m); err != nil {
+			return Outcome{}, err
+		}
+	}
+	return g.Outcome(), nil
+}
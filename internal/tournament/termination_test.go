@@ -0,0 +1,158 @@
+package tournament
+
+import (
+	"strings"
+	"testing"
+
+	"rungine/internal/fen"
+	"rungine/internal/pgn"
+)
+
+func TestDetectTermination(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string
+		moves  string
+		want   Termination
+		result string
+	}{
+		{
+			name:   "ongoing",
+			fen:    fen.StartingFEN,
+			moves:  "e2e4 e7e5",
+			want:   TerminationNone,
+			result: pgn.ResultOngoing,
+		},
+		{
+			name:   "fool's mate",
+			fen:    fen.StartingFEN,
+			moves:  "f2f3 e7e5 g2g4 d8h4",
+			want:   TerminationCheckmate,
+			result: pgn.ResultBlackWins,
+		},
+		{
+			name:   "scholar's mate",
+			fen:    fen.StartingFEN,
+			moves:  "e2e4 e7e5 f1c4 b8c6 d1h5 g8f6 h5f7",
+			want:   TerminationCheckmate,
+			result: pgn.ResultWhiteWins,
+		},
+		{
+			name:   "stalemate",
+			fen:    "7k/8/6Q1/8/8/8/8/K7 w - - 0 1",
+			moves:  "g6f7",
+			want:   TerminationStalemate,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "bare kings after capture",
+			fen:    "8/8/8/3k4/8/4r3/4K3/8 w - - 0 1",
+			moves:  "e2e3",
+			want:   TerminationInsufficientMaterial,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "threefold repetition",
+			fen:    fen.StartingFEN,
+			moves:  "g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8",
+			want:   TerminationThreefoldRepetition,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "fivefold repetition",
+			fen:    fen.StartingFEN,
+			moves:  "g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8 g1f3 g8f6 f3g1 f6g8",
+			want:   TerminationFivefoldRepetition,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "50-move rule",
+			fen:    "8/8/4k3/8/8/4K3/8/R7 w - - 99 80",
+			moves:  "a1a2",
+			want:   TerminationFiftyMoves,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "75-move rule",
+			fen:    "8/8/4k3/8/8/4K3/8/R7 w - - 149 120",
+			moves:  "a1a2",
+			want:   TerminationSeventyFiveMoves,
+			result: pgn.ResultDraw,
+		},
+		{
+			name:   "checkmate beats 50-move rule",
+			fen:    "k7/8/1K6/8/8/8/8/7R w - - 99 80",
+			moves:  "h1h8",
+			want:   TerminationCheckmate,
+			result: pgn.ResultWhiteWins,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start, err := fen.Parse(tc.fen)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+			}
+			got, err := DetectTermination(start, strings.Fields(tc.moves))
+			if err != nil {
+				t.Fatalf("DetectTermination() error: %v", err)
+			}
+			if got.Termination != tc.want {
+				t.Errorf("Termination = %v, want %v", got.Termination, tc.want)
+			}
+			if got.Result != tc.result {
+				t.Errorf("Result = %q, want %q", got.Result, tc.result)
+			}
+		})
+	}
+}
+
+func TestDetectTerminationIllegalMove(t *testing.T) {
+	_, err := DetectTermination(fen.StartingPosition(), []string{"e2e4", "e2e4"})
+	if err == nil {
+		t.Fatal("DetectTermination() with illegal move succeeded")
+	}
+}
+
+func TestRepetitionsResetByPawnMove(t *testing.T) {
+	g := NewGameState(fen.StartingPosition())
+	for _, m := range strings.Fields("g1f3 g8f6 f3g1 f6g8 e2e4 e7e5 g1f3 g8f6 f3g1 f6g8") {
+		if err := g.Play(m); err != nil {
+			t.Fatalf("Play(%q) error: %v", m, err)
+		}
+	}
+	if got := g.Repetitions(); got != 2 {
+		t.Errorf("Repetitions() = %d, want 2", got)
+	}
+	if g.Outcome().IsOver() {
+		t.Errorf("game over after two repetitions: %v", g.Outcome().Termination)
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want bool
+	}{
+		{"8/8/4k3/8/8/4K3/8/8 w - - 0 1", true},
+		{"8/8/4k3/8/8/4K3/8/5N2 w - - 0 1", true},
+		{"8/8/4k3/8/8/4K3/8/5B2 w - - 0 1", true},
+		{"8/8/4k3/3b4/8/4K3/8/5B2 w - - 0 1", true},  // Both bishops on light squares
+		{"8/8/4k3/2b5/8/4K3/8/5B2 w - - 0 1", false}, // Opposite-coloured bishops
+		{"8/8/4k3/8/8/4K3/8/4NN2 w - - 0 1", false},  // Two knights can mate with help
+		{"8/8/4k3/8/8/4K3/8/4BN2 w - - 0 1", false},
+		{"8/8/4k3/8/8/4K3/4P3/8 w - - 0 1", false},
+		{"8/8/4k3/8/8/4K3/8/7R w - - 0 1", false},
+	}
+
+	for _, tc := range tests {
+		pos, err := fen.Parse(tc.fen)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.fen, err)
+		}
+		if got := pos.InsufficientMaterial(); got != tc.want {
+			t.Errorf("InsufficientMaterial(%q) = %v, want %v", tc.fen, got, tc.want)
+		}
+	}
+}
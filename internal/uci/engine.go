@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"os/exec"
+	"strconv"
 	"sync"
 	"time"
+
+	"rungine/internal/fen"
 )
 
 var (
@@ -28,18 +32,33 @@ type Engine struct {
 	process *exec.Cmd
 	stdin   io.WriteCloser
 	stdout  io.ReadCloser
+	outW    *io.PipeWriter // Write ends of stdout and stderr, closed once the process has exited
+	errW    *io.PipeWriter
+
+	state    EngineState
+	stopping bool   // Stop was called, so the process exiting is no crash
+	crash    *Crash // How the last process ended, if it crashed
+	options  map[string]UCIOption
+	chess960 bool
 
-	state   EngineState
-	options map[string]UCIOption
+	startOptions []OptionValue // Applied in order on each start
+	staleOptions []string      // Start options the engine did not report
 
-	outputCh chan ParsedLine
-	infoCh   chan AnalysisInfo
-	doneCh   chan struct{}
+	search *search // Search in progress, fed by the reader goroutine
+	ponder *search // Ponder search awaiting PonderHit or StopPonder
+
+	outputCh   chan ParsedLine
+	infoCh     chan AnalysisInfo
+	bestMoveCh chan BestMove
+	doneCh     chan struct{}
 
 	mu     sync.Mutex
 	ctx    context.Context
 	cancel context.CancelFunc
 
+	transcript *Transcript
+	logFile    *os.File
+
 	logger *slog.Logger
 }
 
@@ -50,6 +69,7 @@ func NewEngine(id, binaryPath string) *Engine {
 		BinaryPath: binaryPath,
 		state:      EngineStateNone,
 		options:    make(map[string]UCIOption),
+		transcript: NewTranscript(DefaultTranscriptSize),
 		logger:     slog.Default().With("engine", id),
 	}
 }
@@ -72,6 +92,36 @@ func (e *Engine) Options() map[string]UCIOption {
 	return opts
 }
 
+// Transcript returns the engine's I/O transcript, which lasts across restarts.
+func (e *Engine) Transcript() *Transcript {
+	return e.transcript
+}
+
+// SetLogFile mirrors the transcript to a file, appending to it, or stops
+// mirroring if path is empty. The previous log file, if any, is closed.
+func (e *Engine) SetLogFile(path string) error {
+	var f *os.File
+	if path != "" {
+		var err error
+		if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644); err != nil {
+			return fmt.Errorf("open engine log: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if f != nil {
+		e.transcript.SetMirror(f)
+	} else {
+		e.transcript.SetMirror(nil)
+	}
+	if e.logFile != nil {
+		e.logFile.Close()
+	}
+	e.logFile = f
+	return nil
+}
+
 // Start launches the engine process and initializes UCI.
 func (e *Engine) Start(ctx context.Context) error {
 	e.mu.Lock()
@@ -80,10 +130,16 @@ func (e *Engine) Start(ctx context.Context) error {
 		return fmt.Errorf("engine already running (state: %s)", e.state)
 	}
 
+	if e.cancel != nil {
+		e.cancel() // Release the context of the previous process
+	}
 	e.ctx, e.cancel = context.WithCancel(ctx)
 	e.state = EngineStateStarting
+	e.stopping = false
+	e.crash = nil
 	e.outputCh = make(chan ParsedLine, 100)
 	e.infoCh = make(chan AnalysisInfo, 100)
+	e.bestMoveCh = make(chan BestMove, 1)
 	e.doneCh = make(chan struct{})
 	e.mu.Unlock()
 
@@ -97,30 +153,41 @@ func (e *Engine) Start(ctx context.Context) error {
 		return fmt.Errorf("stdin pipe: %w", err)
 	}
 
-	e.stdout, err = e.process.StdoutPipe()
-	if err != nil {
-		e.setState(EngineStateError)
-		return fmt.Errorf("stdout pipe: %w", err)
-	}
+	// Wait copies all of stdout and stderr to the pipes before returning, so
+	// that the last words of a crashing engine are not lost
+	var stderr io.Reader
+	e.stdout, e.outW = io.Pipe()
+	stderr, e.errW = io.Pipe()
+	e.process.Stdout, e.process.Stderr = e.outW, e.errW
+	// Don't wait forever for output from children the engine may have left behind
+	e.process.WaitDelay = time.Second
 
 	if err := e.process.Start(); err != nil {
+		e.outW.Close()
+		e.errW.Close()
 		e.setState(EngineStateError)
 		return fmt.Errorf("start process: %w", err)
 	}
 
 	e.logger.Info("engine process started", "pid", e.process.Process.Pid)
 
-	// Start reader goroutine
+	// Start reader goroutines
+	stderrDone := make(chan struct{})
 	go e.readLoop()
+	go e.readStderr(stderr, stderrDone)
 
 	// Start monitor goroutine
-	go e.monitor()
+	go e.monitor(stderrDone)
 
 	// Send UCI init and wait for uciok
 	if err := e.initUCI(); err != nil {
 		e.Stop()
 		return err
 	}
+	if err := e.applyStartOptions(); err != nil {
+		e.Stop()
+		return err
+	}
 
 	return nil
 }
@@ -132,6 +199,7 @@ func (e *Engine) Stop() error {
 		e.mu.Unlock()
 		return nil
 	}
+	e.stopping = true
 	e.mu.Unlock()
 
 	e.logger.Info("stopping engine")
@@ -160,7 +228,14 @@ func (e *Engine) InfoChannel() <-chan AnalysisInfo {
 	return e.infoCh
 }
 
-// SetOption sets a UCI option value.
+// BestMoveChannel returns the channel receiving the result of each search.
+// Only the latest bestmove is kept if nobody is reading.
+func (e *Engine) BestMoveChannel() <-chan BestMove {
+	return e.bestMoveCh
+}
+
+// SetOption sets a UCI option value. Options the engine reported, other than
+// buttons, are also added to the start options to survive restarts.
 func (e *Engine) SetOption(name, value string) error {
 	if e.State() != EngineStateReady && e.State() != EngineStateThinking {
 		return ErrEngineNotRunning
@@ -175,6 +250,9 @@ func (e *Engine) SetOption(name, value string) error {
 	if opt, ok := e.options[name]; ok {
 		opt.Value = value
 		e.options[name] = opt
+		if opt.Type != OptionTypeButton {
+			e.rememberOption(name, value)
+		}
 	}
 	e.mu.Unlock()
 
@@ -182,28 +260,84 @@ func (e *Engine) SetOption(name, value string) error {
 }
 
 // SetPosition sends a position command to the engine.
-func (e *Engine) SetPosition(fen string, moves []string) error {
+// The FEN is validated first, since engines tend to crash on impossible positions.
+func (e *Engine) SetPosition(fenStr string, moves []string) error {
 	if e.State() != EngineStateReady {
 		return ErrEngineNotRunning
 	}
 
-	cmd := BuildPositionCommand(fen, moves)
+	if err := validateFEN(fenStr); err != nil {
+		return fmt.Errorf("invalid position: %w", err)
+	}
+
+	cmd, err := BuildPositionCommand(fenStr, moves, e.Chess960())
+	if err != nil {
+		return fmt.Errorf("invalid position: %w", err)
+	}
 	return e.sendCommand(cmd)
 }
 
-// Go starts the engine searching with the given parameters.
+// SetChess960 switches the engine in or out of Chess960 mode by setting UCI_Chess960.
+// In Chess960 mode SetPosition sends castling moves as king takes rook.
+func (e *Engine) SetChess960(enabled bool) error {
+	if _, ok := e.Options()["UCI_Chess960"]; !ok {
+		return fmt.Errorf("engine %s does not support UCI_Chess960", e.ID)
+	}
+
+	if err := e.SetOption("UCI_Chess960", strconv.FormatBool(enabled)); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.chess960 = enabled
+	e.mu.Unlock()
+	return nil
+}
+
+// Chess960 returns true if the engine is in Chess960 mode.
+func (e *Engine) Chess960() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.chess960
+}
+
+// validateFEN checks that a FEN (or "startpos") describes a position an engine can search.
+func validateFEN(fenStr string) error {
+	if fenStr == "" || fenStr == "startpos" {
+		return nil
+	}
+	pos, err := fen.Parse(fenStr)
+	if err != nil {
+		return err
+	}
+	return pos.Validate()
+}
+
+// Go starts the engine searching with the given parameters, pondering if params.Ponder is set.
 func (e *Engine) Go(params GoParams) error {
 	state := e.State()
 	if state != EngineStateReady {
 		return fmt.Errorf("engine not ready (state: %s)", state)
 	}
 
-	e.setState(EngineStateThinking)
+	if params.Ponder {
+		e.setState(EngineStatePondering)
+	} else {
+		e.setState(EngineStateThinking)
+	}
 
 	cmd := BuildGoCommand(params)
 	return e.sendCommand(cmd)
 }
 
+// NewGame tells the engine that the next position belongs to a different game.
+func (e *Engine) NewGame() error {
+	if e.State() != EngineStateReady {
+		return ErrEngineNotRunning
+	}
+	return e.sendCommand("ucinewgame")
+}
+
 // StopSearch stops the current search.
 func (e *Engine) StopSearch() error {
 	if e.State() != EngineStateThinking && e.State() != EngineStatePondering {
@@ -280,11 +414,10 @@ func (e *Engine) waitFor(responseType string, timeout time.Duration) error {
 			if !ok {
 				return ErrEngineCrashed
 			}
+			// Other lines were already dispatched by readLoop
 			if line.Type == responseType {
 				return nil
 			}
-			// Process other lines while waiting
-			e.handleLine(line)
 		case <-timer.C:
 			return fmt.Errorf("%w: waiting for %s", ErrEngineTimeout, responseType)
 		case <-e.ctx.Done():
@@ -298,6 +431,11 @@ func (e *Engine) handleLine(line ParsedLine) {
 	case "info":
 		info := line.Data.(AnalysisInfo)
 		info.EngineID = e.ID
+		e.mu.Lock()
+		if e.search != nil {
+			e.search.addInfo(info)
+		}
+		e.mu.Unlock()
 		select {
 		case e.infoCh <- info:
 		default:
@@ -309,7 +447,25 @@ func (e *Engine) handleLine(line ParsedLine) {
 			e.infoCh <- info
 		}
 	case "bestmove":
-		e.setState(EngineStateReady)
+		bm, _ := line.Data.(BestMove)
+		e.mu.Lock()
+		pondering := e.state == EngineStatePondering
+		e.state = EngineStateReady
+		if e.search != nil {
+			e.search.complete(bm, pondering)
+			e.search = nil
+		}
+		e.mu.Unlock()
+		select {
+		case e.bestMoveCh <- bm:
+		default:
+			// Previous result never collected, replace it
+			select {
+			case <-e.bestMoveCh:
+			default:
+			}
+			e.bestMoveCh <- bm
+		}
 	}
 }
 
@@ -322,6 +478,7 @@ func (e *Engine) sendCommand(cmd string) error {
 	}
 
 	e.logger.Debug("sending command", "cmd", cmd)
+	e.transcript.Add(DirectionSent, cmd)
 	_, err := fmt.Fprintln(e.stdin, cmd)
 	return err
 }
@@ -329,21 +486,41 @@ func (e *Engine) sendCommand(cmd string) error {
 func (e *Engine) readLoop() {
 	defer close(e.outputCh)
 	defer close(e.infoCh)
+	defer close(e.bestMoveCh)
+	// Unblock the copying of stdout in Wait if we return early
+	defer e.stdout.Close()
 
 	scanner := bufio.NewScanner(e.stdout)
 	for scanner.Scan() {
 		line := scanner.Text()
 		e.logger.Debug("received", "line", line)
+		e.transcript.Add(DirectionReceived, line)
 
 		parsed := ParseLine(line)
-		select {
-		case e.outputCh <- parsed:
-		case <-e.ctx.Done():
+		switch {
+		case parsed.Type == "unknown":
+			e.logger.Info("unrecognized engine output", "line", line)
+		case parsed.Type == "info" && parsed.Data.(AnalysisInfo).String != "":
+			e.logger.Info("engine info string", "text", parsed.Data.(AnalysisInfo).String)
+		}
+		if e.ctx.Err() != nil {
 			return
 		}
-
-		// Also handle info/bestmove directly for channel dispatch
-		e.handleLine(parsed)
+		switch parsed.Type {
+		case "info", "bestmove":
+			// Dispatched to their own channels; outputCh is only drained while
+			// waiting for a response, and a search may send any number of them
+			e.handleLine(parsed)
+		case "unknown", "empty", "id":
+			// Nothing waits for these
+		default:
+			// Handshake lines must not be lost
+			select {
+			case e.outputCh <- parsed:
+			case <-e.ctx.Done():
+				return
+			}
+		}
 	}
 
 	if err := scanner.Err(); err != nil && e.ctx.Err() == nil {
@@ -351,15 +528,68 @@ func (e *Engine) readLoop() {
 	}
 }
 
-func (e *Engine) monitor() {
+// readStderr records the engine's stderr in the transcript until the process exits.
+func (e *Engine) readStderr(r io.Reader, done chan<- struct{}) {
+	defer close(done)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		e.transcript.Add(DirectionStderr, line)
+		e.logger.Info("engine stderr", "line", line)
+	}
+	// Keep draining so that Wait is never blocked on a line too long to scan
+	io.Copy(io.Discard, r)
+}
+
+func (e *Engine) monitor(stderrDone <-chan struct{}) {
 	defer close(e.doneCh)
 
 	err := e.process.Wait()
-	if err != nil && e.ctx.Err() == nil {
-		// Unexpected crash
-		e.logger.Error("engine crashed", "err", err)
-		e.setState(EngineStateError)
+	e.outW.Close()
+	e.errW.Close()
+	// Have the last lines of stderr in the transcript before reporting a crash
+	<-stderrDone
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopping || e.ctx.Err() != nil {
+		return
+	}
+
+	// Unexpected exit, even a clean one
+	crash := &Crash{ExitCode: e.process.ProcessState.ExitCode(), State: e.state, Time: time.Now()}
+	if err != nil {
+		crash.Err = fmt.Errorf("%w: %w", ErrEngineCrashed, err)
+	} else {
+		crash.Err = fmt.Errorf("%w: exited", ErrEngineCrashed)
 	}
+	e.crash = crash
+	e.state = EngineStateError
+	e.logger.Error("engine crashed", "err", err, "exitCode", crash.ExitCode)
+}
+
+// Crash describes how an engine process ended unexpectedly.
+type Crash struct {
+	ExitCode int         // -1 if the process was killed by a signal
+	Err      error       // Wraps ErrEngineCrashed
+	State    EngineState // State of the engine when it crashed
+	Time     time.Time
+}
+
+// Crash returns how the engine's process crashed, or nil if it is running or
+// was stopped. It is only set once Done is closed.
+func (e *Engine) Crash() *Crash {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.crash
+}
+
+// Done returns a channel closed when the engine's process has exited.
+func (e *Engine) Done() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.doneCh
 }
 
 func (e *Engine) setState(state EngineState) {
@@ -4,6 +4,7 @@ package uci
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -210,6 +211,108 @@ func TestEngineStopSearch(t *testing.T) {
 	}
 }
 
+func TestEngineSearch(t *testing.T) {
+	sfPath := getStockfishPath(t)
+
+	engine := NewEngine("test-sf", sfPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer engine.Stop()
+
+	if err := engine.SetOption("MultiPV", "2"); err != nil {
+		t.Fatalf("SetOption() error: %v", err)
+	}
+
+	res, err := engine.Search(ctx, "", []string{"e2e4"}, GoParams{Depth: 10})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if res.BestMove.Move == "" || res.Stopped {
+		t.Errorf("Search() = %+v, want a bestmove without stopping", res.BestMove)
+	}
+	if len(res.Lines) != 2 || res.Best().Depth != 10 || res.Best().PV[0] != res.BestMove.Move {
+		t.Errorf("Lines = %+v, want 2 lines at depth 10 led by the bestmove", res.Lines)
+	}
+	if engine.State() != EngineStateReady {
+		t.Errorf("State() = %v, want Ready", engine.State())
+	}
+
+	// Cancelling an infinite search still returns the engine's answer
+	searchCtx, stop := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer stop()
+	res, err = engine.Search(searchCtx, "", nil, GoParams{Infinite: true})
+	if err != nil {
+		t.Fatalf("Search(infinite) error: %v", err)
+	}
+	if res.BestMove.Move == "" || !res.Stopped || res.Elapsed < 500*time.Millisecond {
+		t.Errorf("Search(infinite) = %+v after %v, want a stopped bestmove", res.BestMove, res.Elapsed)
+	}
+}
+
+func TestEnginePonder(t *testing.T) {
+	sfPath := getStockfishPath(t)
+
+	engine := NewEngine("test-sf", sfPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer engine.Stop()
+
+	if err := engine.SetOption("Ponder", "true"); err != nil {
+		t.Fatalf("SetOption() error: %v", err)
+	}
+
+	clocks := GoParams{WhiteTime: 10 * time.Second, BlackTime: 10 * time.Second}
+	res, err := engine.Search(ctx, "", []string{"e2e4"}, clocks)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if res.BestMove.Ponder == "" {
+		t.Fatalf("bestmove %+v has no ponder move", res.BestMove)
+	}
+
+	// Ponder hit: the time spent pondering is not charged
+	moves := []string{"e2e4", res.BestMove.Move}
+	if err := engine.Ponder("", moves, res.BestMove.Ponder, clocks); err != nil {
+		t.Fatalf("Ponder() error: %v", err)
+	}
+	if engine.State() != EngineStatePondering {
+		t.Errorf("State() = %v, want Pondering", engine.State())
+	}
+	time.Sleep(500 * time.Millisecond)
+	hit, err := engine.PonderHit(ctx)
+	if err != nil {
+		t.Fatalf("PonderHit() error: %v", err)
+	}
+	if hit.BestMove.Move == "" || hit.PonderTime < 500*time.Millisecond {
+		t.Errorf("PonderHit() = %+v after pondering %v", hit.BestMove, hit.PonderTime)
+	}
+
+	// Ponder miss: stop, then search the move actually played
+	moves = append(moves, res.BestMove.Ponder, hit.BestMove.Move)
+	if err := engine.Ponder("", moves, hit.BestMove.Ponder, clocks); err != nil {
+		t.Fatalf("Ponder() error: %v", err)
+	}
+	if err := engine.StopPonder(); err != nil {
+		t.Fatalf("StopPonder() error: %v", err)
+	}
+	if engine.State() != EngineStateReady || engine.Pondering() {
+		t.Errorf("State() = %v after StopPonder, want Ready", engine.State())
+	}
+	if _, err := engine.PonderHit(ctx); !errors.Is(err, ErrNotPondering) {
+		t.Errorf("PonderHit() without pondering error = %v, want ErrNotPondering", err)
+	}
+}
+
 func TestEngineSetOption(t *testing.T) {
 	sfPath := getStockfishPath(t)
 
@@ -243,6 +346,39 @@ func TestEngineSetOption(t *testing.T) {
 	}
 }
 
+func TestEngineStartOptions(t *testing.T) {
+	sfPath := getStockfishPath(t)
+
+	engine := NewEngine("test-sf", sfPath)
+	engine.SetStartOptions([]OptionValue{{Name: "Hash", Value: "32"}, {Name: "Removed Option", Value: "1"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if got := engine.Options()["Hash"].Value; got != "32" {
+		t.Errorf("Hash = %s after start, want 32", got)
+	}
+	if got := engine.StaleOptions(); len(got) != 1 || got[0] != "Removed Option" {
+		t.Errorf("StaleOptions() = %v, want [Removed Option]", got)
+	}
+
+	// Options set while running are applied again on restart
+	if err := engine.SetOption("Threads", "2"); err != nil {
+		t.Fatalf("SetOption(Threads) error: %v", err)
+	}
+	engine.Stop()
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("restart error: %v", err)
+	}
+	defer engine.Stop()
+	if got := engine.Options()["Threads"].Value; got != "2" {
+		t.Errorf("Threads = %s after restart, want 2", got)
+	}
+}
+
 func TestEnginePositionWithMoves(t *testing.T) {
 	sfPath := getStockfishPath(t)
 
@@ -0,0 +1,39 @@
+package uci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEngineKeepsHandshakeLines(t *testing.T) {
+	engine, _ := startFakeEngine(t, fakeEngineOptions, "5000")
+
+	if got := len(engine.Options()); got != 5003 {
+		t.Errorf("%d options, want 5003", got)
+	}
+
+	// readyok must get through while an infinite search floods the engine with info lines
+	if err := engine.SetPosition("startpos", nil); err != nil {
+		t.Fatalf("SetPosition() error: %v", err)
+	}
+	if err := engine.Go(GoParams{Infinite: true}); err != nil {
+		t.Fatalf("Go() error: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if err := engine.IsReady(2 * time.Second); err != nil {
+			t.Fatalf("IsReady() during search error: %v", err)
+		}
+	}
+
+	if err := engine.StopSearch(); err != nil {
+		t.Fatalf("StopSearch() error: %v", err)
+	}
+	select {
+	case bm := <-engine.BestMoveChannel():
+		if bm.Move != "e7e5" {
+			t.Errorf("bestmove %s, want e7e5", bm.Move)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no bestmove after stop")
+	}
+}
@@ -0,0 +1,173 @@
+package uci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The test binary runs as a fake engine when fakeEngineDir is set in its environment.
+const (
+	fakeEngineDir     = "UCI_FAKE_ENGINE_DIR"
+	fakeEngineCrash   = "UCI_FAKE_ENGINE_CRASH"   // "go" or "button": what makes the first run crash
+	fakeEngineOptions = "UCI_FAKE_ENGINE_OPTIONS" // Number of options to report besides its own
+	fakeEnginePonder  = "UCI_FAKE_ENGINE_PONDER"  // "early" to answer a ponder search without waiting
+)
+
+// fakePonderHitTime is how long the fake engine goes on searching after ponderhit.
+const fakePonderHitTime = 20 * time.Millisecond
+
+func TestMain(m *testing.M) {
+	if dir := os.Getenv(fakeEngineDir); dir != "" {
+		fakeEngine(dir, os.Getenv(fakeEngineCrash))
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeEngine speaks just enough UCI for the tests and writes the commands it
+// receives to a file per run in dir. Its first run crashes on "go", or when its
+// Crash button is pressed, depending on crash.
+//
+// A search with limits reports three depths and answers at once; an infinite
+// one reports depths as fast as they are read until stop. Each depth has a line
+// for each MultiPV, the first starting e7e5, the second c7c5 and so on.
+// A ponder search runs like an infinite one until ponderhit, then goes on for
+// fakePonderHitTime, unless fakeEnginePonder is "early".
+func fakeEngine(dir, crash string) {
+	runs, _ := filepath.Glob(filepath.Join(dir, "run*"))
+	f, err := os.Create(filepath.Join(dir, fmt.Sprintf("run%d", len(runs))))
+	if err != nil {
+		os.Exit(2)
+	}
+	defer f.Close()
+	first := len(runs) == 0
+	options, _ := strconv.Atoi(os.Getenv(fakeEngineOptions))
+
+	var mu sync.Mutex
+	say := func(format string, args ...any) {
+		mu.Lock()
+		fmt.Printf(format+"\n", args...)
+		mu.Unlock()
+	}
+	die := func() {
+		fmt.Fprintln(os.Stderr, "fatal: out of memory")
+		os.Exit(3)
+	}
+
+	multiPV := 1
+	var stop, hit, done chan struct{}
+	search := func(endless bool) {
+		stop, hit, done = make(chan struct{}), make(chan struct{}), make(chan struct{})
+		go func(stop, hit, done chan struct{}, lines int) {
+			defer close(done)
+			defer say("bestmove e7e5 ponder g1f3")
+			for depth := 1; endless || depth <= 3; depth++ {
+				for i := 0; i < lines; i++ {
+					say("info depth %d multipv %d score cp %d nodes %d pv %c7%c5 g1f3",
+						depth, i+1, 20+depth-10*i, 1000*depth, 'e'-i*2, 'e'-i*2)
+				}
+				select {
+				case <-stop:
+					return
+				case <-hit:
+					time.Sleep(fakePonderHitTime)
+					endless, hit = false, nil
+				default:
+				}
+			}
+		}(stop, hit, done, multiPV)
+	}
+	finish := func() {
+		if stop != nil {
+			close(stop)
+			<-done
+			stop = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		cmd := scanner.Text()
+		fmt.Fprintln(f, cmd)
+		switch {
+		case cmd == "uci":
+			say("id name Fake")
+			say("option name Hash type spin default 16 min 1 max 1024")
+			say("option name Crash type button")
+			say("option name MultiPV type spin default 1 min 1 max 3")
+			for i := 1; i <= options; i++ {
+				say("option name Option %d type spin default %d min 0 max 1000", i, i)
+			}
+			say("uciok")
+		case cmd == "isready":
+			say("readyok")
+		case cmd == "setoption name Crash" && first && crash == "button":
+			die()
+		case strings.HasPrefix(cmd, "setoption name MultiPV value "):
+			multiPV, _ = strconv.Atoi(strings.TrimPrefix(cmd, "setoption name MultiPV value "))
+		case strings.HasPrefix(cmd, "go"):
+			finish()
+			if first && crash == "go" {
+				say("info depth 1 score cp 20 pv e7e5")
+				die()
+			}
+			ponder := strings.Contains(cmd, "ponder") && os.Getenv(fakeEnginePonder) != "early"
+			search(strings.Contains(cmd, "infinite") || ponder)
+		case cmd == "ponderhit":
+			if hit != nil {
+				close(hit)
+				hit = nil
+			}
+		case cmd == "stop":
+			finish()
+		case cmd == "quit":
+			return
+		}
+	}
+}
+
+// startFakeEngine starts the test binary as a fake engine, with env added to
+// its environment, and returns it with the directory of its command files.
+func startFakeEngine(t *testing.T, env ...string) (*Engine, string) {
+	t.Helper()
+	binary, err := os.Executable()
+	if err != nil {
+		t.Skip("cannot find the test binary to run as a fake engine")
+	}
+	dir := t.TempDir()
+	t.Setenv(fakeEngineDir, dir)
+	for i := 0; i+1 < len(env); i += 2 {
+		t.Setenv(env[i], env[i+1])
+	}
+
+	engine := NewEngine("fake", binary)
+	if err := engine.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { engine.Stop() })
+	return engine, dir
+}
+
+// fakeEngineCommands waits until the fake engine's run has received want, the last
+// command expected, and returns all the commands of the run.
+func fakeEngineCommands(t *testing.T, dir string, run int, want string) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(filepath.Join(dir, fmt.Sprintf("run%d", run)))
+		cmds := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if slices.Contains(cmds, want) || time.Now().After(deadline) {
+			return cmds
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -16,8 +16,16 @@ type EngineManager struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	// Event callback for streaming analysis to frontend
+	// Event callbacks for streaming analysis and crashes to frontend
 	onAnalysis func(info AnalysisInfo)
+	onCrash    func(CrashEvent)
+	onRestart  func(RestartEvent)
+
+	// Crash recovery
+	restartPolicy RestartPolicy
+	crashes       map[string][]time.Time     // Recent crash times per engine
+	analyses      map[string]analysisRequest // Analysis running per engine, for resuming it
+	recoveryMu    sync.Mutex
 
 	// Throttling
 	throttleInterval time.Duration
@@ -36,6 +44,9 @@ func NewEngineManager() *EngineManager {
 		cancel:           cancel,
 		throttleInterval: 50 * time.Millisecond, // 20Hz default
 		lastEmit:         make(map[string]time.Time),
+		restartPolicy:    DefaultRestartPolicy(),
+		crashes:          make(map[string][]time.Time),
+		analyses:         make(map[string]analysisRequest),
 		logger:           slog.Default().With("component", "engine-manager"),
 	}
 }
@@ -83,9 +94,15 @@ func (m *EngineManager) UnregisterEngine(id string) error {
 	delete(m.engines, id)
 	m.mu.Unlock()
 
+	m.recoveryMu.Lock()
+	delete(m.crashes, id)
+	delete(m.analyses, id)
+	m.recoveryMu.Unlock()
+
 	if engine.State() != EngineStateNone && engine.State() != EngineStateStopped {
 		engine.Stop()
 	}
+	engine.SetLogFile("")
 
 	m.logger.Info("engine unregistered", "id", id)
 	return nil
@@ -111,11 +128,12 @@ func (m *EngineManager) ListEngines() []EngineInfo {
 	infos := make([]EngineInfo, 0, len(m.engines))
 	for _, e := range m.engines {
 		infos = append(infos, EngineInfo{
-			ID:         e.ID,
-			Name:       e.Name,
-			Author:     e.Author,
-			BinaryPath: e.BinaryPath,
-			State:      e.State().String(),
+			ID:           e.ID,
+			Name:         e.Name,
+			Author:       e.Author,
+			BinaryPath:   e.BinaryPath,
+			State:        e.State().String(),
+			StaleOptions: e.StaleOptions(),
 		})
 	}
 	return infos
@@ -144,6 +162,9 @@ func (m *EngineManager) StopEngine(id string) error {
 	if err != nil {
 		return err
 	}
+	m.recoveryMu.Lock()
+	delete(m.analyses, id)
+	m.recoveryMu.Unlock()
 	return engine.Stop()
 }
 
@@ -166,6 +187,10 @@ func (m *EngineManager) StartAnalysis(fen string, moves []string, engineIDs []st
 		if err := engine.Go(params); err != nil {
 			return fmt.Errorf("start analysis on %s: %w", id, err)
 		}
+
+		m.recoveryMu.Lock()
+		m.analyses[id] = analysisRequest{fen: fen, moves: moves, params: params}
+		m.recoveryMu.Unlock()
 	}
 	return nil
 }
@@ -179,6 +204,9 @@ func (m *EngineManager) StopAnalysis(engineIDs []string) error {
 			lastErr = err
 			continue
 		}
+		m.recoveryMu.Lock()
+		delete(m.analyses, id)
+		m.recoveryMu.Unlock()
 		if err := engine.StopSearch(); err != nil {
 			lastErr = err
 		}
@@ -208,12 +236,21 @@ func (m *EngineManager) Shutdown() {
 }
 
 // streamAnalysis reads from an engine's info channel and dispatches to the callback.
+// When the channel closes because the engine crashed, it hands over to crash recovery.
 func (m *EngineManager) streamAnalysis(engine *Engine) {
-	infoCh := engine.InfoChannel()
+	infoCh, done := engine.InfoChannel(), engine.Done()
 	for {
 		select {
 		case info, ok := <-infoCh:
 			if !ok {
+				select {
+				case <-done:
+				case <-m.ctx.Done():
+					return
+				}
+				if crash := engine.Crash(); crash != nil {
+					m.handleCrash(engine, crash)
+				}
 				return
 			}
 			m.emitThrottled(info)
@@ -261,4 +298,7 @@ type EngineInfo struct {
 	Author     string `json:"author"`
 	BinaryPath string `json:"binaryPath"`
 	State      string `json:"state"`
+
+	// StaleOptions are saved options the engine did not report when it last started
+	StaleOptions []string `json:"staleOptions"`
 }
@@ -0,0 +1,137 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidOptionValue = errors.New("invalid option value")
+
+// startReadyTimeout bounds the isready sync after applying the start options,
+// which can take a while for options like Hash.
+const startReadyTimeout = 30 * time.Second
+
+// OptionValue is a value given to a UCI option.
+type OptionValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Validate checks a value against the option's type and the range or choices
+// the engine reported for it.
+func (o UCIOption) Validate(value string) error {
+	switch o.Type {
+	case OptionTypeSpin:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %q is not a number", ErrInvalidOptionValue, o.Name, value)
+		}
+		if (o.Min != nil && n < *o.Min) || (o.Max != nil && n > *o.Max) {
+			return fmt.Errorf("%w: %s: %d is out of range", ErrInvalidOptionValue, o.Name, n)
+		}
+	case OptionTypeCheck:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%w: %s: %q is not true or false", ErrInvalidOptionValue, o.Name, value)
+		}
+	case OptionTypeCombo:
+		if !slices.ContainsFunc(o.Vars, func(v string) bool { return strings.EqualFold(v, value) }) {
+			return fmt.Errorf("%w: %s: %q is not one of %v", ErrInvalidOptionValue, o.Name, value, o.Vars)
+		}
+	}
+	return nil
+}
+
+// Clamp brings a spin value within the option's range.
+func (o UCIOption) Clamp(n int) int {
+	if o.Max != nil {
+		n = min(n, *o.Max)
+	}
+	if o.Min != nil {
+		n = max(n, *o.Min)
+	}
+	return n
+}
+
+// SetStartOptions sets the option values applied, in order, each time the engine
+// starts, such as those saved in its config. SetOption adds to them.
+func (e *Engine) SetStartOptions(opts []OptionValue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.startOptions = slices.Clone(opts)
+}
+
+// StartOptions returns the option values applied each time the engine starts.
+func (e *Engine) StartOptions() []OptionValue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.startOptions)
+}
+
+// ForgetOption removes an option from the start options. The running
+// engine keeps its current value.
+func (e *Engine) ForgetOption(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.startOptions = slices.DeleteFunc(e.startOptions, func(o OptionValue) bool { return o.Name == name })
+	e.staleOptions = slices.DeleteFunc(e.staleOptions, func(n string) bool { return n == name })
+}
+
+// StaleOptions returns the start options the engine did not report when it
+// last started, for example after an upgrade renamed them. They are not sent.
+func (e *Engine) StaleOptions() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.staleOptions)
+}
+
+// rememberOption adds an option value to the start options, moving it to the
+// end so that it is applied after the options set before it. Must be called with e.mu held.
+func (e *Engine) rememberOption(name, value string) {
+	e.startOptions = slices.DeleteFunc(e.startOptions, func(o OptionValue) bool { return o.Name == name })
+	e.startOptions = append(e.startOptions, OptionValue{Name: name, Value: value})
+}
+
+// applyStartOptions sends the start options once the engine has reported its
+// own after uciok, then waits for it to be ready.
+func (e *Engine) applyStartOptions() error {
+	e.mu.Lock()
+	opts := slices.Clone(e.startOptions)
+	e.staleOptions = nil
+	e.mu.Unlock()
+	if len(opts) == 0 {
+		return nil
+	}
+
+	for _, o := range opts {
+		e.mu.Lock()
+		opt, ok := e.options[o.Name]
+		if !ok {
+			e.staleOptions = append(e.staleOptions, o.Name)
+		}
+		e.mu.Unlock()
+		if !ok {
+			e.logger.Warn("engine no longer has option, not setting it", "option", o.Name)
+			continue
+		}
+		if opt.Type == OptionTypeButton {
+			continue
+		}
+
+		if err := e.sendCommand(BuildSetOptionCommand(o.Name, o.Value)); err != nil {
+			return err
+		}
+		e.mu.Lock()
+		opt.Value = o.Value
+		e.options[o.Name] = opt
+		e.mu.Unlock()
+	}
+
+	if err := e.IsReady(startReadyTimeout); err != nil {
+		return fmt.Errorf("applying options: %w", err)
+	}
+	return nil
+}
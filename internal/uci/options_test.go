@@ -0,0 +1,46 @@
+package uci
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOptionValidate(t *testing.T) {
+	hash := UCIOption{Name: "Hash", Type: OptionTypeSpin, Min: intPtr(1), Max: intPtr(1024)}
+	wdl := UCIOption{Name: "UCI_ShowWDL", Type: OptionTypeCheck}
+	backend := UCIOption{Name: "Backend", Type: OptionTypeCombo, Vars: []string{"cuda-auto", "blas"}}
+	path := UCIOption{Name: "SyzygyPath", Type: OptionTypeString}
+
+	tests := []struct {
+		opt   UCIOption
+		value string
+		valid bool
+	}{
+		{hash, "256", true},
+		{hash, "1", true},
+		{hash, "1024", true},
+		{hash, "0", false},
+		{hash, "2048", false},
+		{hash, "auto", false},
+		{wdl, "true", true},
+		{wdl, "yes", false},
+		{backend, "BLAS", true},
+		{backend, "metal", false},
+		{path, "/tb/3-4-5", true},
+	}
+	for _, tc := range tests {
+		err := tc.opt.Validate(tc.value)
+		if tc.valid && err != nil {
+			t.Errorf("%s.Validate(%q) error: %v", tc.opt.Name, tc.value, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidOptionValue) {
+			t.Errorf("%s.Validate(%q) error = %v, want ErrInvalidOptionValue", tc.opt.Name, tc.value, err)
+		}
+	}
+
+	for n, want := range map[int]int{0: 1, 512: 512, 4096: 1024} {
+		if got := hash.Clamp(n); got != want {
+			t.Errorf("Clamp(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package uci
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	"rungine/internal/fen"
 )
 
 // ParsedLine represents a parsed UCI response line.
@@ -142,7 +145,8 @@ func parseInfoLine(parts []string) ParsedLine {
 				i++
 			}
 		case "string":
-			// Rest of line is string output, ignore for now
+			// Rest of line is free text, such as a warning or the network in use
+			info.String = strings.Join(parts[i+1:], " ")
 			i = len(parts)
 		}
 	}
@@ -300,8 +304,18 @@ func BuildGoCommand(p GoParams) string {
 	return strings.Join(parts, " ")
 }
 
-// BuildPositionCommand constructs a "position" command string.
-func BuildPositionCommand(fen string, moves []string) string {
+// BuildPositionCommand constructs a "position" command string. For an engine
+// running with UCI_Chess960 enabled, castling moves are rewritten as king takes
+// rook (e.g., "e1g1" becomes "e1h1"), which requires replaying the game, so the
+// moves must then be legal; other moves are passed through unchanged.
+func BuildPositionCommand(fen string, moves []string, chess960 bool) (string, error) {
+	if chess960 {
+		var err error
+		if moves, err = chess960Moves(fen, moves); err != nil {
+			return "", err
+		}
+	}
+
 	var parts []string
 	parts = append(parts, "position")
 
@@ -316,7 +330,7 @@ func BuildPositionCommand(fen string, moves []string) string {
 		parts = append(parts, moves...)
 	}
 
-	return strings.Join(parts, " ")
+	return strings.Join(parts, " "), nil
 }
 
 // BuildSetOptionCommand constructs a "setoption" command string.
@@ -326,3 +340,26 @@ func BuildSetOptionCommand(name, value string) string {
 	}
 	return "setoption name " + name + " value " + value
 }
+
+// chess960Moves replays moves from a position, writing castling as king takes rook.
+func chess960Moves(fenStr string, moves []string) ([]string, error) {
+	start := fenStr
+	if start == "" || start == "startpos" {
+		start = fen.StartingFEN
+	}
+	pos, err := fen.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]string, 0, len(moves))
+	for i, mv := range moves {
+		m, err := pos.ParseUCIMove(mv)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		converted = append(converted, pos.Chess960MoveString(m))
+		pos.MakeMove(m)
+	}
+	return converted, nil
+}
@@ -135,6 +135,17 @@ func TestParseInfoLine(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "info string",
+			input:    "info string ERROR: Network file nn-bad.nnue was not loaded successfully.",
+			wantType: "info",
+			check: func(t *testing.T, info AnalysisInfo) {
+				want := "ERROR: Network file nn-bad.nnue was not loaded successfully."
+				if info.String != want {
+					t.Errorf("String = %q, want %q", info.String, want)
+				}
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -372,6 +383,11 @@ func TestBuildGoCommand(t *testing.T) {
 			params: GoParams{Depth: 10, SearchMoves: []string{"e2e4", "d2d4"}},
 			want:   "go depth 10 searchmoves e2e4 d2d4",
 		},
+		{
+			name:   "ponder",
+			params: GoParams{Ponder: true, WhiteTime: time.Minute, BlackTime: 50 * time.Second},
+			want:   "go ponder wtime 60000 btime 50000",
+		},
 	}
 
 	for _, tc := range tests {
@@ -422,12 +438,59 @@ func TestBuildPositionCommand(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got := BuildPositionCommand(tc.fen, tc.moves)
+			got, err := BuildPositionCommand(tc.fen, tc.moves, false)
+			if err != nil {
+				t.Fatalf("BuildPositionCommand() error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("BuildPositionCommand() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildPositionCommandChess960(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string
+		want  string
+	}{
+		{
+			name:  "standard castling rewritten",
+			fen:   "startpos",
+			moves: []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5", "e1g1"},
+			want:  "position startpos moves e2e4 e7e5 g1f3 b8c6 f1c4 f8c5 e1h1",
+		},
+		{
+			name:  "king takes rook passed through",
+			fen:   "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			moves: []string{"e1a1", "e8h8"},
+			want:  "position fen r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1 moves e1a1 e8h8",
+		},
+		{
+			name:  "shredder fen",
+			fen:   "bnrbkrqn/pppppppp/8/8/8/8/PPPPPPPP/BNRBKRQN w FCfc - 0 1",
+			moves: []string{"h1g3"},
+			want:  "position fen bnrbkrqn/pppppppp/8/8/8/8/PPPPPPPP/BNRBKRQN w FCfc - 0 1 moves h1g3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := BuildPositionCommand(tc.fen, tc.moves, true)
+			if err != nil {
+				t.Fatalf("BuildPositionCommand() error: %v", err)
+			}
 			if got != tc.want {
 				t.Errorf("BuildPositionCommand() = %s, want %s", got, tc.want)
 			}
 		})
 	}
+
+	if _, err := BuildPositionCommand("startpos", []string{"e2e5"}, true); err == nil {
+		t.Error("BuildPositionCommand() with illegal move succeeded")
+	}
 }
 
 func TestBuildSetOptionCommand(t *testing.T) {
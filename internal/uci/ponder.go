@@ -0,0 +1,94 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotPondering = errors.New("engine not pondering")
+
+// Ponder starts the engine thinking on the opponent's time: it searches the
+// position after moves and the predicted ponderMove, usually the ponder move of
+// the engine's last bestmove. params holds the clocks as they stand after the
+// engine's own move; the opponent's clock keeps running while the engine ponders.
+// Engines with a Ponder option should have it set to true beforehand.
+//
+// When the opponent plays ponderMove, call PonderHit to turn the ponder search
+// into a normal one; otherwise call StopPonder and search the actual position.
+func (e *Engine) Ponder(fenStr string, moves []string, ponderMove string, params GoParams) error {
+	if ponderMove == "" {
+		return errors.New("no move to ponder on")
+	}
+
+	params.Ponder = true
+	params.Infinite = false
+	s, err := e.startSearch(fenStr, append(moves[:len(moves):len(moves)], ponderMove), params)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.ponder = s
+	e.mu.Unlock()
+	return nil
+}
+
+// PonderHit tells the engine that the opponent played the move it pondered on
+// and blocks until its bestmove, like Search. The engine's clock only starts
+// running now: Elapsed is counted from ponderhit and the time spent pondering
+// is returned as PonderTime. If the engine answered while still pondering,
+// its bestmove is returned at once.
+func (e *Engine) PonderHit(ctx context.Context) (*SearchResult, error) {
+	s, err := e.takePonder()
+	if err != nil {
+		return nil, err
+	}
+	defer e.endSearch(s)
+
+	e.mu.Lock()
+	if e.state == EngineStatePondering {
+		now := time.Now()
+		s.result.PonderTime = now.Sub(s.start)
+		s.start = now
+		e.state = EngineStateThinking
+		e.mu.Unlock()
+		if err := e.sendCommand("ponderhit"); err != nil {
+			return nil, err
+		}
+	} else {
+		e.mu.Unlock()
+	}
+	return e.wait(ctx, s)
+}
+
+// StopPonder ends pondering after the opponent played another move than the
+// predicted one. It stops the search and discards the engine's answer, leaving
+// the engine ready to search the actual position.
+func (e *Engine) StopPonder() error {
+	s, err := e.takePonder()
+	if err != nil {
+		return err
+	}
+	defer e.endSearch(s)
+	_, err = e.stopAndWait(s)
+	return err
+}
+
+// Pondering returns true if a ponder search awaits PonderHit or StopPonder.
+func (e *Engine) Pondering() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ponder != nil
+}
+
+func (e *Engine) takePonder() (*search, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.ponder
+	if s == nil {
+		return nil, ErrNotPondering
+	}
+	e.ponder = nil
+	return s, nil
+}
@@ -0,0 +1,107 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ponderClock is the clock of a ponder test game, as it stands after the engine's move.
+var ponderClock = GoParams{WhiteTime: time.Minute, BlackTime: time.Minute}
+
+func TestPonderHit(t *testing.T) {
+	engine, dir := startFakeEngine(t)
+
+	if err := engine.Ponder("startpos", []string{"e2e4", "e7e5"}, "g1f3", ponderClock); err != nil {
+		t.Fatalf("Ponder() error: %v", err)
+	}
+	if !engine.Pondering() || engine.State() != EngineStatePondering {
+		t.Fatalf("Pondering() = %v, State() = %v, want pondering", engine.Pondering(), engine.State())
+	}
+
+	const ponderTime = 100 * time.Millisecond
+	time.Sleep(ponderTime)
+	res, err := engine.PonderHit(context.Background())
+	if err != nil {
+		t.Fatalf("PonderHit() error: %v", err)
+	}
+	if res.BestMove.Move != "e7e5" || res.Stopped {
+		t.Errorf("bestmove %+v, stopped %v, want e7e5", res.BestMove, res.Stopped)
+	}
+	// Only the time after ponderhit is charged to the engine
+	if res.PonderTime < ponderTime || res.Elapsed < fakePonderHitTime || res.Elapsed >= res.PonderTime {
+		t.Errorf("Elapsed = %v, PonderTime = %v, want at least %v and %v", res.Elapsed, res.PonderTime, fakePonderHitTime, ponderTime)
+	}
+	if len(res.Infos) == 0 {
+		t.Error("no infos, want those of the ponder search too")
+	}
+	if engine.Pondering() || engine.State() != EngineStateReady {
+		t.Errorf("Pondering() = %v, State() = %v after bestmove, want ready", engine.Pondering(), engine.State())
+	}
+
+	cmds := fakeEngineCommands(t, dir, 0, "ponderhit")
+	for _, want := range []string{"position startpos moves e2e4 e7e5 g1f3", "go ponder wtime 60000 btime 60000", "ponderhit"} {
+		if !slices.Contains(cmds, want) {
+			t.Errorf("engine got %q, want %q", cmds, want)
+		}
+	}
+}
+
+func TestPonderEarlyBestMove(t *testing.T) {
+	engine, dir := startFakeEngine(t, fakeEnginePonder, "early")
+
+	if err := engine.Ponder("startpos", []string{"e2e4", "e7e5"}, "g1f3", ponderClock); err != nil {
+		t.Fatalf("Ponder() error: %v", err)
+	}
+	// The engine answers while still pondering
+	deadline := time.Now().Add(5 * time.Second)
+	for engine.State() != EngineStateReady && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !engine.Pondering() {
+		t.Error("Pondering() = false before PonderHit")
+	}
+
+	res, err := engine.PonderHit(context.Background())
+	if err != nil {
+		t.Fatalf("PonderHit() error: %v", err)
+	}
+	if res.BestMove.Move != "e7e5" || res.Elapsed != 0 || res.PonderTime <= 0 {
+		t.Errorf("bestmove %+v, Elapsed = %v, PonderTime = %v, want e7e5 on the opponent's time only", res.BestMove, res.Elapsed, res.PonderTime)
+	}
+	if cmds := fakeEngineCommands(t, dir, 0, "ponderhit"); slices.Contains(cmds, "ponderhit") {
+		t.Errorf("engine got %q, want no ponderhit after its bestmove", cmds)
+	}
+}
+
+func TestStopPonder(t *testing.T) {
+	engine, dir := startFakeEngine(t)
+
+	if err := engine.Ponder("startpos", []string{"e2e4", "e7e5"}, "g1f3", ponderClock); err != nil {
+		t.Fatalf("Ponder() error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := engine.StopPonder(); err != nil {
+		t.Fatalf("StopPonder() error: %v", err)
+	}
+	if engine.Pondering() || engine.State() != EngineStateReady {
+		t.Errorf("Pondering() = %v, State() = %v after StopPonder, want ready", engine.Pondering(), engine.State())
+	}
+	if cmds := fakeEngineCommands(t, dir, 0, "stop"); !slices.Contains(cmds, "stop") {
+		t.Errorf("engine got %q, want stop", cmds)
+	}
+	if _, err := engine.PonderHit(context.Background()); !errors.Is(err, ErrNotPondering) {
+		t.Errorf("PonderHit() after StopPonder error = %v, want ErrNotPondering", err)
+	}
+
+	// The actual position is searched without the discarded answer getting in the way
+	res, err := engine.Search(context.Background(), "startpos", []string{"e2e4", "d7d5"}, GoParams{Depth: 3})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if res.Stopped || len(res.Infos) != 3 || res.PonderTime != 0 {
+		t.Errorf("search after miss: %d infos, stopped %v, PonderTime %v, want a search of its own", len(res.Infos), res.Stopped, res.PonderTime)
+	}
+}
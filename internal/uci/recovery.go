@@ -0,0 +1,193 @@
+package uci
+
+import (
+	"fmt"
+	"time"
+)
+
+// crashStderrLines is the number of stderr lines reported with a crash.
+const crashStderrLines = 20
+
+// RestartPolicy controls how the manager restarts engines that crash.
+type RestartPolicy struct {
+	// MaxCrashes is the number of crashes within Window after which the engine
+	// is left stopped. Zero disables restarting.
+	MaxCrashes int
+	Window     time.Duration
+
+	// Backoff is the delay before the first restart, doubled for each
+	// further crash within Window up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// ResumeAnalysis restarts the analysis the engine was running when it crashed.
+	ResumeAnalysis bool
+}
+
+// DefaultRestartPolicy gives up after three crashes in five minutes.
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		MaxCrashes:     3,
+		Window:         5 * time.Minute,
+		Backoff:        time.Second,
+		MaxBackoff:     30 * time.Second,
+		ResumeAnalysis: true,
+	}
+}
+
+// delay returns how long to wait before restarting after the n-th crash within
+// the window, and false if the engine should not be restarted.
+func (p RestartPolicy) delay(n int) (time.Duration, bool) {
+	if n >= p.MaxCrashes {
+		return 0, false
+	}
+	d := p.Backoff
+	for i := 1; i < n && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d, true
+}
+
+// CrashEvent reports an engine crash to the frontend.
+type CrashEvent struct {
+	EngineID  string        `json:"engineId"`
+	ExitCode  int           `json:"exitCode"` // -1 if killed by a signal
+	Error     string        `json:"error"`
+	Stderr    []string      `json:"stderr"`    // Last lines the engine wrote to stderr
+	Crashes   int           `json:"crashes"`   // Within the policy window, including this one
+	RestartIn time.Duration `json:"restartIn"` // Delay before the restart
+	GaveUp    bool          `json:"gaveUp"`    // The engine is left stopped
+}
+
+// RestartEvent reports the outcome of restarting a crashed engine.
+type RestartEvent struct {
+	EngineID string `json:"engineId"`
+	Resumed  bool   `json:"resumed"` // The analysis running at the crash was resumed
+	Error    string `json:"error"`   // Empty if the restart succeeded
+}
+
+// analysisRequest is an analysis started by StartAnalysis, kept for resuming it.
+type analysisRequest struct {
+	fen    string
+	moves  []string
+	params GoParams
+}
+
+// SetRestartPolicy sets how crashed engines are restarted.
+func (m *EngineManager) SetRestartPolicy(p RestartPolicy) {
+	m.recoveryMu.Lock()
+	m.restartPolicy = p
+	m.recoveryMu.Unlock()
+}
+
+// SetCrashCallback sets the callback invoked when a started engine crashes.
+// The callback is invoked from a goroutine; it should be safe for concurrent use.
+func (m *EngineManager) SetCrashCallback(cb func(CrashEvent)) {
+	m.mu.Lock()
+	m.onCrash = cb
+	m.mu.Unlock()
+}
+
+// SetRestartCallback sets the callback invoked after trying to restart a crashed engine.
+// The callback is invoked from a goroutine; it should be safe for concurrent use.
+func (m *EngineManager) SetRestartCallback(cb func(RestartEvent)) {
+	m.mu.Lock()
+	m.onRestart = cb
+	m.mu.Unlock()
+}
+
+// handleCrash reports a crash of an engine started by StartEngine and, as the
+// restart policy allows, restarts it.
+func (m *EngineManager) handleCrash(engine *Engine, crash *Crash) {
+	now := time.Now()
+	m.recoveryMu.Lock()
+	policy := m.restartPolicy
+	crashes := m.crashes[engine.ID][:0]
+	for _, t := range m.crashes[engine.ID] {
+		if now.Sub(t) < policy.Window {
+			crashes = append(crashes, t)
+		}
+	}
+	crashes = append(crashes, now)
+	m.crashes[engine.ID] = crashes
+	analysis, resume := m.analyses[engine.ID]
+	m.recoveryMu.Unlock()
+
+	delay, restart := policy.delay(len(crashes))
+	event := CrashEvent{
+		EngineID:  engine.ID,
+		ExitCode:  crash.ExitCode,
+		Error:     crash.Err.Error(),
+		Stderr:    lastStderr(engine.Transcript(), crashStderrLines),
+		Crashes:   len(crashes),
+		RestartIn: delay,
+		GaveUp:    !restart,
+	}
+	m.logger.Warn("engine crashed", "id", engine.ID, "exitCode", crash.ExitCode, "crashes", len(crashes), "restart", restart)
+
+	m.mu.RLock()
+	onCrash, onRestart := m.onCrash, m.onRestart
+	m.mu.RUnlock()
+	if onCrash != nil {
+		onCrash(event)
+	}
+	if !restart {
+		return
+	}
+
+	select {
+	case <-time.After(delay):
+	case <-m.ctx.Done():
+		return
+	}
+	// The engine may have been unregistered or stopped by hand meanwhile
+	if current, err := m.GetEngine(engine.ID); err != nil || current != engine || engine.State() != EngineStateError {
+		return
+	}
+
+	resume = resume && policy.ResumeAnalysis && crash.State == EngineStateThinking
+	result := RestartEvent{EngineID: engine.ID, Resumed: resume}
+	if err := m.restart(engine, resume, analysis); err != nil {
+		m.logger.Error("engine restart failed", "id", engine.ID, "err", err)
+		result.Resumed = false
+		result.Error = err.Error()
+	} else {
+		m.logger.Info("engine restarted", "id", engine.ID, "resumed", resume)
+	}
+	if onRestart != nil {
+		onRestart(result)
+	}
+}
+
+// restart starts a crashed engine again, which replays the options that were
+// set on it, and, if resume is set, restarts the analysis.
+func (m *EngineManager) restart(engine *Engine, resume bool, analysis analysisRequest) error {
+	if err := m.StartEngine(engine.ID); err != nil {
+		return err
+	}
+	if !resume {
+		return nil
+	}
+
+	if err := engine.SetPosition(analysis.fen, analysis.moves); err != nil {
+		return fmt.Errorf("resume analysis: %w", err)
+	}
+	if err := engine.Go(analysis.params); err != nil {
+		return fmt.Errorf("resume analysis: %w", err)
+	}
+	return nil
+}
+
+// lastStderr returns the last n lines of stderr kept in a transcript.
+func lastStderr(t *Transcript, n int) []string {
+	var lines []string
+	for _, l := range t.Lines(0) {
+		if l.Direction == DirectionStderr {
+			lines = append(lines, l.Text)
+		}
+	}
+	return lines[max(len(lines)-n, 0):]
+}
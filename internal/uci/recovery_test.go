@@ -0,0 +1,160 @@
+package uci
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyDelay(t *testing.T) {
+	p := RestartPolicy{MaxCrashes: 5, Window: time.Minute, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		crashes int
+		want    time.Duration
+		restart bool
+	}{
+		{1, time.Second, true},
+		{2, 2 * time.Second, true},
+		{3, 4 * time.Second, true},
+		{4, 5 * time.Second, true},
+		{5, 0, false},
+		{6, 0, false},
+	}
+	for _, tc := range tests {
+		got, restart := p.delay(tc.crashes)
+		if got != tc.want || restart != tc.restart {
+			t.Errorf("delay(%d) = %v, %v, want %v, %v", tc.crashes, got, restart, tc.want, tc.restart)
+		}
+	}
+
+	if _, restart := (RestartPolicy{}).delay(1); restart {
+		t.Error("zero policy restarts, want restarting disabled")
+	}
+}
+
+func TestLastStderr(t *testing.T) {
+	tr := NewTranscript(10)
+	tr.Add(DirectionSent, "go")
+	tr.Add(DirectionStderr, "warning")
+	tr.Add(DirectionReceived, "info depth 1")
+	tr.Add(DirectionStderr, "CUDA error")
+	tr.Add(DirectionStderr, "aborting")
+
+	if got := strings.Join(lastStderr(tr, 2), "|"); got != "CUDA error|aborting" {
+		t.Errorf("lastStderr(2) = %q", got)
+	}
+	if got := strings.Join(lastStderr(tr, 5), "|"); got != "warning|CUDA error|aborting" {
+		t.Errorf("lastStderr(5) = %q", got)
+	}
+}
+
+func TestCrashRecovery(t *testing.T) {
+	binary, err := os.Executable()
+	if err != nil {
+		t.Skip("cannot find the test binary to run as a fake engine")
+	}
+
+	tests := []struct {
+		name   string
+		crash  string
+		params GoParams
+		resume bool
+	}{
+		{name: "crash while thinking", crash: "go", params: GoParams{Infinite: true}, resume: true},
+		{name: "crash while ready", crash: "button", params: GoParams{Depth: 1}, resume: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv(fakeEngineDir, dir)
+			t.Setenv(fakeEngineCrash, tc.crash)
+
+			const backoff = 100 * time.Millisecond
+			manager := NewEngineManager()
+			defer manager.Shutdown()
+			manager.SetRestartPolicy(RestartPolicy{MaxCrashes: 3, Window: time.Minute, Backoff: backoff, ResumeAnalysis: true})
+			crashes := make(chan CrashEvent, 1)
+			restarts := make(chan RestartEvent, 1)
+			var crashed time.Time
+			manager.SetCrashCallback(func(ev CrashEvent) {
+				crashed = time.Now()
+				crashes <- ev
+			})
+			manager.SetRestartCallback(func(ev RestartEvent) { restarts <- ev })
+
+			if err := manager.RegisterEngine("fake", binary); err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.StartEngine("fake"); err != nil {
+				t.Fatalf("StartEngine() error: %v", err)
+			}
+			engine, _ := manager.GetEngine("fake")
+			if err := engine.SetOption("Hash", "64"); err != nil {
+				t.Fatalf("SetOption() error: %v", err)
+			}
+			if err := manager.StartAnalysis("startpos", []string{"e2e4"}, []string{"fake"}, tc.params); err != nil {
+				t.Fatalf("StartAnalysis() error: %v", err)
+			}
+			if tc.crash == "button" {
+				// Crash once the search is over, with the analysis still on record
+				select {
+				case <-engine.BestMoveChannel():
+				case <-time.After(5 * time.Second):
+					t.Fatal("no bestmove")
+				}
+				if err := engine.SetOption("Crash", ""); err != nil {
+					t.Fatalf("SetOption() error: %v", err)
+				}
+			}
+
+			var crash CrashEvent
+			select {
+			case crash = <-crashes:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no crash event")
+			}
+			if crash.EngineID != "fake" || crash.ExitCode != 3 || crash.Crashes != 1 || crash.RestartIn != backoff || crash.GaveUp {
+				t.Errorf("crash event = %+v", crash)
+			}
+			if !slices.Contains(crash.Stderr, "fatal: out of memory") {
+				t.Errorf("crash event stderr = %q, want the engine's last words", crash.Stderr)
+			}
+
+			var restart RestartEvent
+			select {
+			case restart = <-restarts:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no restart event")
+			}
+			if elapsed := time.Since(crashed); elapsed < backoff {
+				t.Errorf("restarted after %v, want a backoff of %v", elapsed, backoff)
+			}
+			if restart.Error != "" || restart.Resumed != tc.resume {
+				t.Errorf("restart event = %+v, want resumed %v", restart, tc.resume)
+			}
+			if state := engine.State(); state != EngineStateReady && state != EngineStateThinking {
+				t.Errorf("State() after restart = %v", state)
+			}
+
+			last := "setoption name Hash value 64"
+			if tc.resume {
+				last = "go infinite"
+			}
+			cmds := fakeEngineCommands(t, dir, 1, last)
+			if !slices.Contains(cmds, "setoption name Hash value 64") {
+				t.Errorf("restarted engine got %q, want the Hash option replayed", cmds)
+			}
+			if slices.Contains(cmds, "setoption name Crash") {
+				t.Errorf("restarted engine got %q, want the button not replayed", cmds)
+			}
+			resumed := slices.Contains(cmds, "position startpos moves e2e4") && slices.Contains(cmds, "go infinite")
+			if resumed != tc.resume {
+				t.Errorf("restarted engine got %q, want analysis resumed %v", cmds, tc.resume)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package uci
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StopGrace is how long Search waits for the bestmove after sending stop.
+const StopGrace = 5 * time.Second
+
+// SearchResult is the outcome of a search run to completion by Search.
+type SearchResult struct {
+	BestMove BestMove
+
+	// Lines holds the last info with a PV for each MultiPV line, first line first.
+	// A line the engine never reported is left zero.
+	Lines []AnalysisInfo
+
+	// Infos holds every info line received during the search, in order.
+	Infos []AnalysisInfo
+
+	// Elapsed is the time from sending go, or ponderhit after pondering, to
+	// receiving bestmove: the time to charge to the engine's clock.
+	Elapsed time.Duration
+	// PonderTime is the time spent pondering before ponderhit, which is free.
+	PonderTime time.Duration
+
+	Stopped bool // The search was cut short by cancelling the context
+}
+
+// Best returns the info of the first line, zero if the engine sent none.
+func (r *SearchResult) Best() AnalysisInfo {
+	if len(r.Lines) == 0 {
+		return AnalysisInfo{}
+	}
+	return r.Lines[0]
+}
+
+// search collects the output of the search Search is waiting for.
+type search struct {
+	result SearchResult
+	start  time.Time
+	done   chan BestMove
+}
+
+func (s *search) addInfo(info AnalysisInfo) {
+	s.result.Infos = append(s.result.Infos, info)
+	if len(info.PV) == 0 {
+		return
+	}
+	n := max(info.MultiPV, 1)
+	for len(s.result.Lines) < n {
+		s.result.Lines = append(s.result.Lines, AnalysisInfo{})
+	}
+	s.result.Lines[n-1] = info
+}
+
+// Search sets up the position, searches it with the given limits and blocks
+// until the engine answers with bestmove. If ctx is done first, Search sends
+// stop and still returns the engine's answer, with Stopped set, unless it does
+// not come within StopGrace. Info lines keep flowing to InfoChannel meanwhile.
+//
+// A search without limits only ends when ctx is done. To ponder, use Ponder.
+func (e *Engine) Search(ctx context.Context, fenStr string, moves []string, params GoParams) (*SearchResult, error) {
+	params.Ponder = false
+	s, err := e.startSearch(fenStr, moves, params)
+	if err != nil {
+		return nil, err
+	}
+	defer e.endSearch(s)
+	return e.wait(ctx, s)
+}
+
+// startSearch sets up the position and starts a search, which handleLine feeds from then on.
+func (e *Engine) startSearch(fenStr string, moves []string, params GoParams) (*search, error) {
+	if err := e.SetPosition(fenStr, moves); err != nil {
+		return nil, err
+	}
+
+	s := &search{start: time.Now(), done: make(chan BestMove, 1)}
+	e.mu.Lock()
+	e.search = s
+	e.mu.Unlock()
+
+	if err := e.Go(params); err != nil {
+		e.endSearch(s)
+		return nil, err
+	}
+	return s, nil
+}
+
+// endSearch stops feeding s, if the bestmove has not done so already.
+func (e *Engine) endSearch(s *search) {
+	e.mu.Lock()
+	if e.search == s {
+		e.search = nil
+	}
+	e.mu.Unlock()
+}
+
+// wait blocks until the search ends, stopping it if ctx is done first.
+func (e *Engine) wait(ctx context.Context, s *search) (*SearchResult, error) {
+	select {
+	case bm := <-s.done:
+		return s.finish(bm, false), nil
+	case <-ctx.Done():
+		return e.stopAndWait(s)
+	case <-e.doneCh:
+		return nil, ErrEngineCrashed
+	}
+}
+
+// stopAndWait sends stop and waits up to StopGrace for the bestmove.
+func (e *Engine) stopAndWait(s *search) (*SearchResult, error) {
+	e.StopSearch()
+
+	timer := time.NewTimer(StopGrace)
+	defer timer.Stop()
+	select {
+	case bm := <-s.done:
+		return s.finish(bm, true), nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: waiting for bestmove", ErrEngineTimeout)
+	case <-e.doneCh:
+		return nil, ErrEngineCrashed
+	}
+}
+
+// complete records the bestmove; the reader goroutine no longer touches s afterwards.
+// An engine that answers while still pondering has used none of its own time.
+func (s *search) complete(bm BestMove, pondering bool) {
+	if pondering {
+		s.result.PonderTime = time.Since(s.start)
+	} else {
+		s.result.Elapsed = time.Since(s.start)
+	}
+	s.done <- bm
+}
+
+// finish returns the result once the bestmove has been received.
+func (s *search) finish(bm BestMove, stopped bool) *SearchResult {
+	s.result.BestMove = bm
+	s.result.Stopped = stopped
+	return &s.result
+}
@@ -0,0 +1,79 @@
+package uci
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	engine, _ := startFakeEngine(t)
+	if err := engine.SetOption("MultiPV", "2"); err != nil {
+		t.Fatalf("SetOption() error: %v", err)
+	}
+
+	res, err := engine.Search(context.Background(), "startpos", []string{"e2e4"}, GoParams{Depth: 3})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if res.BestMove.Move != "e7e5" || res.BestMove.Ponder != "g1f3" || res.Stopped {
+		t.Errorf("bestmove %+v, stopped %v, want e7e5 pondering g1f3", res.BestMove, res.Stopped)
+	}
+	if len(res.Infos) != 6 {
+		t.Errorf("%d infos, want 6", len(res.Infos))
+	}
+	if len(res.Lines) != 2 {
+		t.Fatalf("%d lines, want 2", len(res.Lines))
+	}
+	for i, want := range []string{"e7e5", "c7c5"} {
+		line := res.Lines[i]
+		if line.Depth != 3 || line.MultiPV != i+1 || len(line.PV) == 0 || line.PV[0] != want {
+			t.Errorf("line %d = depth %d multipv %d pv %v, want depth 3 starting %s", i+1, line.Depth, line.MultiPV, line.PV, want)
+		}
+	}
+	if best := res.Best(); best.PV[0] != "e7e5" {
+		t.Errorf("Best() starts %s, want e7e5", best.PV[0])
+	}
+	if res.Elapsed <= 0 || res.PonderTime != 0 {
+		t.Errorf("Elapsed = %v, PonderTime = %v, want only Elapsed", res.Elapsed, res.PonderTime)
+	}
+	if engine.State() != EngineStateReady {
+		t.Errorf("State() = %v, want ready", engine.State())
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	engine, dir := startFakeEngine(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := engine.Search(ctx, "startpos", nil, GoParams{Infinite: true})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if !res.Stopped || res.BestMove.Move != "e7e5" {
+		t.Errorf("bestmove %+v, stopped %v, want e7e5 after stop", res.BestMove, res.Stopped)
+	}
+	if len(res.Infos) == 0 || res.Best().Depth == 0 {
+		t.Errorf("%d infos, best line %+v, want the search so far", len(res.Infos), res.Best())
+	}
+	if res.Elapsed < 50*time.Millisecond {
+		t.Errorf("Elapsed = %v, want at least until the cancel", res.Elapsed)
+	}
+	if cmds := fakeEngineCommands(t, dir, 0, "stop"); !slices.Contains(cmds, "stop") {
+		t.Errorf("engine got %q, want stop", cmds)
+	}
+	if engine.State() != EngineStateReady {
+		t.Errorf("State() = %v, want ready", engine.State())
+	}
+
+	// The engine is ready for the next search, which only gets its own output
+	res, err = engine.Search(context.Background(), "startpos", nil, GoParams{Depth: 3})
+	if err != nil {
+		t.Fatalf("Search() after cancel error: %v", err)
+	}
+	if res.Stopped || len(res.Infos) != 3 {
+		t.Errorf("next search: %d infos, stopped %v, want 3 infos", len(res.Infos), res.Stopped)
+	}
+}
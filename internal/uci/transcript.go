@@ -0,0 +1,118 @@
+package uci
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultTranscriptSize is the number of lines an engine's transcript keeps.
+const DefaultTranscriptSize = 2000
+
+// Direction tells where a transcript line came from.
+type Direction string
+
+const (
+	DirectionSent     Direction = "sent"     // Command written to the engine's stdin
+	DirectionReceived Direction = "received" // Line read from the engine's stdout
+	DirectionStderr   Direction = "stderr"   // Line read from the engine's stderr
+)
+
+// TranscriptLine is one line of engine I/O.
+type TranscriptLine struct {
+	Seq       uint64    `json:"seq"` // Increases by one per line, from 1
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Text      string    `json:"text"`
+}
+
+// String formats the line as in a log file, e.g., "12:00:01.250 > go depth 20".
+func (l TranscriptLine) String() string {
+	arrow := "<"
+	switch l.Direction {
+	case DirectionSent:
+		arrow = ">"
+	case DirectionStderr:
+		arrow = "!"
+	}
+	return l.Time.Format("15:04:05.000") + " " + arrow + " " + l.Text
+}
+
+// Transcript keeps the most recent lines sent to and received from an engine
+// in a ring buffer, optionally mirroring every line to a writer.
+// It is safe for concurrent use.
+type Transcript struct {
+	mu     sync.Mutex
+	lines  []TranscriptLine
+	next   int    // Index in lines of the next line to write
+	seq    uint64 // Seq of the last line added
+	mirror io.Writer
+}
+
+// NewTranscript creates a transcript keeping up to size lines.
+func NewTranscript(size int) *Transcript {
+	if size <= 0 {
+		size = DefaultTranscriptSize
+	}
+	return &Transcript{lines: make([]TranscriptLine, 0, size)}
+}
+
+// Add records a line.
+func (t *Transcript) Add(dir Direction, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq++
+	line := TranscriptLine{Seq: t.seq, Time: time.Now(), Direction: dir, Text: text}
+	if len(t.lines) < cap(t.lines) {
+		t.lines = append(t.lines, line)
+	} else {
+		t.lines[t.next] = line
+	}
+	t.next = (t.next + 1) % cap(t.lines)
+
+	if t.mirror != nil {
+		// A failing log file must not disturb the engine
+		fmt.Fprintln(t.mirror, line)
+	}
+}
+
+// Lines returns the lines still kept with a Seq greater than after, oldest first.
+// Pass 0 for all of them, or the Seq of the last line seen to tail the transcript.
+func (t *Transcript) Lines(after uint64) []TranscriptLine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := t.seq - uint64(len(t.lines)) + 1
+	skip := 0
+	if after >= oldest {
+		skip = int(min(after-oldest+1, uint64(len(t.lines))))
+	}
+
+	lines := make([]TranscriptLine, 0, len(t.lines)-skip)
+	start := 0
+	if len(t.lines) == cap(t.lines) {
+		start = t.next
+	}
+	for i := skip; i < len(t.lines); i++ {
+		lines = append(lines, t.lines[(start+i)%len(t.lines)])
+	}
+	return lines
+}
+
+// Clear discards the lines kept. Sequence numbers keep increasing.
+func (t *Transcript) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = t.lines[:0]
+	t.next = 0
+}
+
+// SetMirror sets a writer every line is also written to, one per line as
+// formatted by TranscriptLine.String, or nil to stop mirroring.
+func (t *Transcript) SetMirror(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mirror = w
+}
@@ -0,0 +1,79 @@
+package uci
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func transcriptTexts(lines []TranscriptLine) string {
+	texts := make([]string, len(lines))
+	for i, l := range lines {
+		texts[i] = l.Text
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestTranscript(t *testing.T) {
+	tr := NewTranscript(3)
+	if got := tr.Lines(0); len(got) != 0 {
+		t.Errorf("empty Lines(0) = %v", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		tr.Add(DirectionReceived, fmt.Sprint(i))
+	}
+
+	tests := []struct {
+		after uint64
+		want  string
+	}{
+		{0, "3,4,5"},
+		{1, "3,4,5"},
+		{3, "4,5"},
+		{4, "5"},
+		{5, ""},
+		{9, ""},
+	}
+	for _, tc := range tests {
+		lines := tr.Lines(tc.after)
+		if got := transcriptTexts(lines); got != tc.want {
+			t.Errorf("Lines(%d) = %q, want %q", tc.after, got, tc.want)
+		}
+		for _, l := range lines {
+			if l.Text != fmt.Sprint(l.Seq) {
+				t.Errorf("line %q has Seq %d", l.Text, l.Seq)
+			}
+		}
+	}
+
+	tr.Clear()
+	tr.Add(DirectionSent, "6")
+	if got := tr.Lines(0); transcriptTexts(got) != "6" || got[0].Seq != 6 {
+		t.Errorf("after Clear, Lines(0) = %v", got)
+	}
+}
+
+func TestTranscriptMirror(t *testing.T) {
+	tr := NewTranscript(10)
+	var buf bytes.Buffer
+	tr.SetMirror(&buf)
+	tr.Add(DirectionSent, "go depth 20")
+	tr.Add(DirectionReceived, "bestmove e2e4")
+	tr.Add(DirectionStderr, "segmentation fault")
+	tr.SetMirror(nil)
+	tr.Add(DirectionSent, "quit")
+
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{"> go depth 20", "< bestmove e2e4", "! segmentation fault"}
+	if len(got) != len(want) {
+		t.Fatalf("mirrored %q, want %d lines", got, len(want))
+	}
+	for i := range want {
+		// Lines start with the time, "15:04:05.000 "
+		if len(got[i]) < 13 || got[i][13:] != want[i] {
+			t.Errorf("line %d = %q, want time and %q", i, got[i], want[i])
+		}
+	}
+}
@@ -105,6 +105,7 @@ type AnalysisInfo struct {
 	CurrMoveNumber int
 	HashFull       int // Per mille
 	TBHits         int64
+	String         string // Text of an "info string" line
 	Timestamp      time.Time
 }
 